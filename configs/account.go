@@ -0,0 +1,15 @@
+package configs
+
+import "os"
+
+type account struct {
+	DATABASE_PATH string
+}
+
+func initAccount() *account {
+	result := &account{
+		DATABASE_PATH: os.Getenv("ACCOUNT_DATABASE_PATH"),
+	}
+
+	return result
+}
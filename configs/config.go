@@ -12,9 +12,25 @@ var S3 *s3
 // Imgur :nodoc:
 var Imgur *imgur
 
+// Session :nodoc:
+var Session *session
+
+// Redis :nodoc:
+var Redis *redis
+
+// Account :nodoc:
+var Account *account
+
+// Socket :nodoc:
+var Socket *socket
+
 func init() {
 	Service = initService()
 	Constant = initConstant()
 	S3 = initS3()
 	Imgur = initImgur()
+	Session = initSession()
+	Redis = initRedis()
+	Account = initAccount()
+	Socket = initSocket()
 }
@@ -0,0 +1,25 @@
+package configs
+
+import (
+	"os"
+
+	"github.com/aryuuu/cepex-server/utils/converter"
+)
+
+type redis struct {
+	ADDRESS  string
+	PASSWORD string
+	DB       int
+}
+
+func initRedis() *redis {
+	var db, _ = converter.ToInt(os.Getenv("REDIS_DB"))
+
+	result := &redis{
+		ADDRESS:  os.Getenv("REDIS_ADDRESS"),
+		PASSWORD: os.Getenv("REDIS_PASSWORD"),
+		DB:       db,
+	}
+
+	return result
+}
@@ -1,16 +1,39 @@
 package configs
 
-import "os"
+import (
+	"os"
+	"strings"
+)
 
 type service struct {
 	Port        string
 	ServiceName string
+	// AllowedOrigins may open websocket connections, none means only the
+	// origin the server is reached at and "*" means any
+	AllowedOrigins []string
+	// TrustedProxies are the addresses or CIDR ranges of the proxies whose
+	// X-Forwarded-For header is believed
+	TrustedProxies []string
 }
 
 func initService() *service {
 	result := &service{
-		Port:        os.Getenv("PORT"),
-		ServiceName: os.Getenv("SERVICE_NAME"),
+		Port:           os.Getenv("PORT"),
+		ServiceName:    os.Getenv("SERVICE_NAME"),
+		AllowedOrigins: listFromEnv("ALLOWED_ORIGINS"),
+		TrustedProxies: listFromEnv("TRUSTED_PROXIES"),
+	}
+
+	return result
+}
+
+// listFromEnv reads a comma separated list, leaving out empty items
+func listFromEnv(key string) []string {
+	result := []string{}
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
 	}
 
 	return result
@@ -0,0 +1,43 @@
+package configs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"os"
+	"time"
+
+	"github.com/aryuuu/cepex-server/utils/converter"
+)
+
+type session struct {
+	Secret      string
+	GracePeriod time.Duration
+	// IsGenerated is set when SESSION_SECRET is missing and the secret has
+	// been made up for this process alone
+	IsGenerated bool
+}
+
+func initSession() *session {
+	secret := os.Getenv("SESSION_SECRET")
+	isGenerated := secret == ""
+	if isGenerated {
+		log.Print("SESSION_SECRET is not set, session tokens will not survive a restart")
+		randomBytes := make([]byte, 32)
+		rand.Read(randomBytes)
+		secret = hex.EncodeToString(randomBytes)
+	}
+
+	gracePeriod, err := converter.ToInt(os.Getenv("SESSION_GRACE_PERIOD"))
+	if err != nil || gracePeriod <= 0 {
+		gracePeriod = 60
+	}
+
+	result := &session{
+		Secret:      secret,
+		GracePeriod: time.Duration(gracePeriod) * time.Second,
+		IsGenerated: isGenerated,
+	}
+
+	return result
+}
@@ -0,0 +1,54 @@
+package configs
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/aryuuu/cepex-server/utils/converter"
+)
+
+type socket struct {
+	// PingInterval is how often the server pings a connection
+	PingInterval time.Duration
+	// PongWait is how long a connection may stay silent before it is
+	// considered dead
+	PongWait  time.Duration
+	WriteWait time.Duration
+	// MaxMessageSize is the largest frame a client may send, in bytes
+	MaxMessageSize int64
+}
+
+func initSocket() *socket {
+	pingInterval := durationFromEnv("SOCKET_PING_INTERVAL", 25)
+	pongWait := durationFromEnv("SOCKET_PONG_WAIT", 60)
+	if pongWait <= pingInterval {
+		log.Print("SOCKET_PONG_WAIT should be longer than SOCKET_PING_INTERVAL, using twice the interval")
+		pongWait = 2 * pingInterval
+	}
+
+	maxMessageSize, err := converter.ToInt(os.Getenv("SOCKET_MAX_MESSAGE_SIZE"))
+	if err != nil || maxMessageSize <= 0 {
+		maxMessageSize = 4096
+	}
+
+	result := &socket{
+		PingInterval:   pingInterval,
+		PongWait:       pongWait,
+		WriteWait:      durationFromEnv("SOCKET_WRITE_WAIT", 10),
+		MaxMessageSize: int64(maxMessageSize),
+	}
+
+	return result
+}
+
+// durationFromEnv reads a number of seconds, falling back to the default
+// when it is not set or not positive
+func durationFromEnv(key string, fallback int) time.Duration {
+	seconds, err := converter.ToInt(os.Getenv(key))
+	if err != nil || seconds <= 0 {
+		seconds = fallback
+	}
+
+	return time.Duration(seconds) * time.Second
+}
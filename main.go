@@ -5,26 +5,43 @@ import (
 	"net/http"
 
 	"github.com/aryuuu/cepex-server/configs"
+	accountModel "github.com/aryuuu/cepex-server/models/account"
+	gameModel "github.com/aryuuu/cepex-server/models/game"
 	"github.com/aryuuu/cepex-server/repositories"
 	"github.com/aryuuu/cepex-server/routes"
 	"github.com/aryuuu/cepex-server/usecases"
+	"github.com/aryuuu/cepex-server/utils/codec"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 )
 
 func main() {
+	checkSessionSecret()
+
 	r := new(mux.Router)
+	allowedOrigins := configs.Service.AllowedOrigins
+	if len(allowedOrigins) == 0 {
+		log.Print("ALLOWED_ORIGINS is not set, websocket connections are only accepted from the same origin")
+	}
+
+	corsOrigins := allowedOrigins
+	if len(corsOrigins) == 0 {
+		corsOrigins = []string{"*"}
+	}
 	cors := handlers.CORS(
-		handlers.AllowedOrigins([]string{"*"}),
+		handlers.AllowedOrigins(corsOrigins),
+		handlers.AllowedHeaders([]string{"Authorization", "Content-Type"}),
 	)
 	r.Use(cors)
 
 	upgrader := websocket.Upgrader{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
-		CheckOrigin:     func(r *http.Request) bool { return true },
+		CheckOrigin:     routes.NewOriginChecker(allowedOrigins),
+		Subprotocols:    codec.Subprotocols,
 	}
 
 	httpClient := new(http.Client)
@@ -32,8 +49,11 @@ func main() {
 	// s3Repo := repositories.NewS3Repo(configureS3())
 	imageRepository := repositories.NewImgurRepo(httpClient)
 
+	roomRepository, replayRepository, broker := configureRoomStore()
+
 	profileUsecase := usecases.NewProfileUsecase(imageRepository)
-	gameUsecase := usecases.NewGameUsecase()
+	accountUsecase := configureAccounts()
+	gameUsecase := usecases.NewGameUsecase(roomRepository, replayRepository, broker, accountUsecase)
 
 	healthcheckRouter := r.PathPrefix("/healthcheck").Subrouter()
 	profileRouter := r.PathPrefix("/profile").Subrouter()
@@ -41,7 +61,11 @@ func main() {
 
 	routes.InitHealthcheckRouter(healthcheckRouter)
 	routes.InitProfileRouter(profileRouter, profileUsecase)
-	routes.InitGameRouter(gameRouter, upgrader, gameUsecase)
+	clientIP := routes.NewClientIPResolver(configs.Service.TrustedProxies)
+	routes.InitGameRouter(gameRouter, upgrader, clientIP, gameUsecase, accountUsecase)
+	if accountUsecase != nil {
+		routes.InitAccountRouter(r, accountUsecase)
+	}
 
 	srv := &http.Server{
 		Addr:    ":" + configs.Service.Port,
@@ -52,6 +76,58 @@ func main() {
 	log.Fatal(srv.ListenAndServe())
 }
 
+// checkSessionSecret refuses to start with a secret of this process alone
+// when tokens have to be verified by other nodes or outlive a restart
+func checkSessionSecret() {
+	if !configs.Session.IsGenerated {
+		return
+	}
+
+	if configs.Redis.ADDRESS != "" {
+		log.Fatal("SESSION_SECRET must be set when rooms are shared through Redis, other nodes could not verify tickets and session tokens")
+	}
+
+	if configs.Account.DATABASE_PATH != "" {
+		log.Fatal("SESSION_SECRET must be set when accounts are enabled, account tokens would not survive a restart")
+	}
+}
+
+func configureRoomStore() (gameModel.RoomRepository, gameModel.ReplayRepository, gameModel.Broker) {
+	if configs.Redis.ADDRESS == "" {
+		log.Print("REDIS_ADDRESS is not set, rooms will be kept in memory of a single node")
+		return repositories.NewMemoryRoomRepo(), repositories.NewMemoryReplayRepo(), repositories.NewMemoryBroker()
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     configs.Redis.ADDRESS,
+		Password: configs.Redis.PASSWORD,
+		DB:       configs.Redis.DB,
+	})
+
+	return repositories.NewRedisRoomRepo(client), repositories.NewRedisReplayRepo(client), repositories.NewRedisBroker(client)
+}
+
+// configureAccounts opens the account store, accounts are disabled when no
+// database is configured
+func configureAccounts() accountModel.AccountUsecase {
+	if configs.Account.DATABASE_PATH == "" {
+		log.Print("ACCOUNT_DATABASE_PATH is not set, players will play as guests")
+		return nil
+	}
+
+	db, err := repositories.OpenSQLite(configs.Account.DATABASE_PATH)
+	if err != nil {
+		log.Fatalf("Failed to open account database: %v", err)
+	}
+
+	accountRepository, err := repositories.NewSQLiteAccountRepo(db)
+	if err != nil {
+		log.Fatalf("Failed to prepare account database: %v", err)
+	}
+
+	return usecases.NewAccountUsecase(accountRepository)
+}
+
 // TODO: reuse S3
 // func configureS3() *session.Session {
 // 	s, err := session.NewSession(&aws.Config{
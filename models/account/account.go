@@ -0,0 +1,43 @@
+package account
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrAccountNotFound    = errors.New("Account not found")
+	ErrUsernameTaken      = errors.New("Username is already taken")
+	ErrWrongCredentials   = errors.New("Wrong username or password")
+	ErrInvalidCredentials = errors.New("Username should be 3 to 32 characters and password at least 8")
+)
+
+// AccountUsecase registers players and keeps their global rating
+type AccountUsecase interface {
+	Register(username, password string) (*Account, string, error)
+	Login(username, password string) (*Account, string, error)
+	Authenticate(token string) (string, error)
+	GetLeaderboard(limit int) ([]Account, error)
+	RecordGame(ranking []string) error
+}
+
+// AccountRepository stores accounts, RecordGame rates a finished game
+// atomically so that concurrent games never overwrite each other
+type AccountRepository interface {
+	Create(account *Account) error
+	Get(accountID string) (*Account, error)
+	GetByUsername(username string) (*Account, error)
+	List(limit int) ([]Account, error)
+	RecordGame(ranking []string) error
+}
+
+// Account is a player identity that outlives the rooms it plays in
+type Account struct {
+	AccountID    string    `json:"id_account"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	Rating       int       `json:"rating"`
+	GamesPlayed  int       `json:"games_played"`
+	Wins         int       `json:"wins"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,52 @@
+package account
+
+import "math"
+
+const (
+	// InitialRating is the rating of an account that has not played yet
+	InitialRating = 1200
+
+	kFactor = 32
+)
+
+// Rate computes the new Elo ratings after a game, given the ratings of the
+// players in the order they finished, winner first. Every player is scored
+// against each of the others as if they had played a duel
+func Rate(ratings []int) []int {
+	result := make([]int, len(ratings))
+	copy(result, ratings)
+
+	if len(ratings) < 2 {
+		return result
+	}
+
+	k := float64(kFactor) / float64(len(ratings)-1)
+	for i := range ratings {
+		delta := 0.0
+		for j := range ratings {
+			if i == j {
+				continue
+			}
+
+			expected := 1 / (1 + math.Pow(10, float64(ratings[j]-ratings[i])/400))
+			actual := 0.0
+			if i < j {
+				actual = 1
+			}
+			delta += k * (actual - expected)
+		}
+
+		result[i] += int(math.Round(delta))
+	}
+
+	return result
+}
+
+// RecordFinish updates the account with the outcome of a game
+func (a *Account) RecordFinish(rating int, isWinner bool) {
+	a.Rating = rating
+	a.GamesPlayed++
+	if isWinner {
+		a.Wins++
+	}
+}
@@ -0,0 +1,38 @@
+package account
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRateDuel(t *testing.T) {
+	result := Rate([]int{1200, 1200})
+	if !reflect.DeepEqual([]int{1216, 1184}, result) {
+		t.Errorf("unexpected ratings %v", result)
+	}
+}
+
+func TestRateUpset(t *testing.T) {
+	result := Rate([]int{1000, 1400})
+	if result[0]-1000 <= 16 {
+		t.Errorf("beating a stronger player should be worth more, got %v", result)
+	}
+	if result[0]+result[1] != 2400 {
+		t.Errorf("ratings should be zero sum, got %v", result)
+	}
+}
+
+func TestRateMultiplayer(t *testing.T) {
+	ratings := []int{1200, 1200, 1200, 1200}
+	result := Rate(ratings)
+
+	for i := 1; i < len(result); i++ {
+		if result[i] >= result[i-1] {
+			t.Errorf("a better finish should gain more, got %v", result)
+		}
+	}
+
+	if !reflect.DeepEqual([]int{1200, 1200, 1200, 1200}, ratings) {
+		t.Errorf("ratings given should be left alone, got %v", ratings)
+	}
+}
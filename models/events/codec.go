@@ -0,0 +1,121 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// subprotocols a client can ask for when opening a websocket, clients asking
+// for none get JSON
+const (
+	JSONSubprotocol    = "cepex.json.v1"
+	MsgpackSubprotocol = "cepex.msgpack.v1"
+	ProtoSubprotocol   = "cepex.proto.v1"
+)
+
+// Codec turns the frames of a websocket into the JSON the server works with
+// and back, every subprotocol has its own
+type Codec interface {
+	// FrameType is the websocket message type frames are sent as
+	FrameType() int
+	// DecodeRequest turns a frame sent by a client into an envelope
+	DecodeRequest(frame []byte) (json.RawMessage, error)
+	// EncodeMessage turns a message of the server into a frame
+	EncodeMessage(message json.RawMessage) ([]byte, error)
+}
+
+// messageTypes lists what the server sends for each event type, codecs that
+// need a schema build theirs from it
+var messageTypes = map[string]interface{}{
+	CreateRoomEvent:                  CreateRoomResponse{},
+	JoinRoomEvent:                    JoinRoomResponse{},
+	JoinRoomBroadcastEvent:           JoinRoomBroadcast{},
+	LeaveRoomEvent:                   LeaveRoomResponse{},
+	LeaveRoomBroadcastEvent:          LeaveRoomBroadcast{},
+	KickPlayerEvent:                  HostActionResponse{},
+	BanPlayerEvent:                   HostActionResponse{},
+	TransferHostEvent:                HostActionResponse{},
+	LockRoomEvent:                    HostActionResponse{},
+	PlayerKickedBroadcastEvent:       PlayerKickedBroadcast{},
+	LockRoomBroadcastEvent:           LockRoomBroadcast{},
+	VoteKickEvent:                    VoteKickPlayerResponse{},
+	VoteKickPlayerEvent:              VoteKickPlayerResponse{},
+	VoteKickBroadcastEvent:           VoteKickPlayerBroadcast{},
+	VoteKickTallyEvent:               VoteKickTallyBroadcast{},
+	VoteKickResultEvent:              VoteKickResultBroadcast{},
+	StartGameEvent:                   StartGameResponse{},
+	StartGameBroadcastEvent:          StartGameBroadcast{},
+	EndGameBroadcastEvent:            EndGameBroadcast{},
+	InitialHandEvent:                 InitialHandResponse{},
+	PlayCardEvent:                    PlayCardResponse{},
+	PlayCardBroadcastEvent:           PlayCardBroadcast{},
+	TurnBroadcastEvent:               TurnBroadcast{},
+	DeadPlayerEvent:                  DeadPlayerBroadcast{},
+	ChangeHostBroadcastEvent:         ChangeHostBroadcast{},
+	MessageBroadcastEvent:            MessageBroadcast{},
+	NotificationBroadcastEvent:       NotificationBroadcast{},
+	ResumeSessionEvent:               ResumeSessionResponse{},
+	PlayerDisconnectedEvent:          PlayerDisconnectedBroadcast{},
+	PlayerReconnectedEvent:           PlayerReconnectedBroadcast{},
+	TurnTimerBroadcastEvent:          TurnTimerBroadcast{},
+	PromoteSpectatorEvent:            PromoteSpectatorResponse{},
+	PromoteSpectatorBroadcastEvent:   PromoteSpectatorBroadcast{},
+	UpdateRoomSettingsEvent:          UpdateRoomSettingsResponse{},
+	UpdateRoomSettingsBroadcastEvent: UpdateRoomSettingsBroadcast{},
+	LobbyRoomsEvent:                  LobbyRoomsResponse{},
+	LobbyUpdateEvent:                 LobbyUpdateBroadcast{},
+	QuickMatchEvent:                  QuickMatchResponse{},
+	AddBotEvent:                      AddBotResponse{},
+	RemoveBotEvent:                   RemoveBotResponse{},
+	LegalMovesEvent:                  LegalMovesResponse{},
+	ReshuffleBroadcastEvent:          ReshuffleBroadcast{},
+	RoundEndBroadcastEvent:           RoundEndBroadcast{},
+	MatchEndBroadcastEvent:           MatchEndBroadcast{},
+	GetLeaderboardEvent:              LeaderboardResponse{},
+	LeaderboardBroadcastEvent:        LeaderboardResponse{},
+	RoomStateEvent:                   RoomStateResponse{},
+	ErrorEvent:                       ErrorResponse{},
+}
+
+// MessageTypes returns the event types the server sends, sorted
+func MessageTypes() []string {
+	result := []string{}
+	for eventType := range messageTypes {
+		result = append(result, eventType)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// MessageType returns the struct the server sends for an event type
+func MessageType(eventType string) (reflect.Type, bool) {
+	message, ok := messageTypes[eventType]
+	if !ok {
+		return nil, false
+	}
+
+	return reflect.TypeOf(message), true
+}
+
+// RequestTypes returns the event types clients are allowed to send, sorted
+func RequestTypes() []string {
+	result := []string{}
+	for eventType := range registry {
+		result = append(result, eventType)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// PayloadType returns the payload struct a request of an event type carries
+func PayloadType(eventType string) (reflect.Type, bool) {
+	newPayload, ok := registry[eventType]
+	if !ok {
+		return nil, false
+	}
+
+	return reflect.TypeOf(newPayload()).Elem(), true
+}
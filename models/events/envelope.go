@@ -0,0 +1,140 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// ProtocolVersion is the version of the envelope clients are expected to
+// send their requests in
+const ProtocolVersion = 1
+
+const maxRequestIDLength = 64
+
+// codes of the error event
+const (
+	CodeUnsupportedVersion = "unsupported-version"
+	CodeMalformedRequest   = "malformed-request"
+	CodeUnknownType        = "unknown-type"
+	CodeInvalidPayload     = "invalid-payload"
+	CodeUnauthorized       = "unauthorized"
+)
+
+// Envelope wraps every request a client sends, ID is picked by the client
+// and sent back along with every answer to the request. A request sent again
+// with the same ID is answered again rather than run twice
+type Envelope struct {
+	Version int             `json:"v"`
+	Type    string          `json:"type"`
+	ID      string          `json:"id"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// ProtocolError is why a request could not be decoded
+type ProtocolError struct {
+	Code      string
+	Request   string
+	RequestID string
+	Detail    string
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Code, e.Detail)
+}
+
+// Response is the error event to send back to the client
+func (e *ProtocolError) Response() ErrorResponse {
+	return NewErrorResponse(e.Code, e.Request, e.RequestID, e.Detail)
+}
+
+// DecodeRequest unwraps a request of a client and checks its payload
+// against what its type expects
+func DecodeRequest(data []byte) (GameRequest, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return GameRequest{}, &ProtocolError{Code: CodeMalformedRequest, Detail: "Request is not a valid envelope"}
+	}
+
+	if envelope.Version != ProtocolVersion {
+		return GameRequest{}, &ProtocolError{
+			Code:      CodeUnsupportedVersion,
+			Request:   envelope.Type,
+			RequestID: envelope.ID,
+			Detail:    fmt.Sprintf("Protocol version %v is not supported, use %v", envelope.Version, ProtocolVersion),
+		}
+	}
+
+	if envelope.ID == "" || len(envelope.ID) > maxRequestIDLength {
+		return GameRequest{}, &ProtocolError{
+			Code:    CodeMalformedRequest,
+			Request: envelope.Type,
+			Detail:  fmt.Sprintf("Request should have an id of at most %v characters", maxRequestIDLength),
+		}
+	}
+
+	payload := NewPayload(envelope.Type)
+	if payload == nil {
+		return GameRequest{}, &ProtocolError{
+			Code:      CodeUnknownType,
+			Request:   envelope.Type,
+			RequestID: envelope.ID,
+			Detail:    fmt.Sprintf("Unknown request type %q", envelope.Type),
+		}
+	}
+
+	if len(envelope.Payload) > 0 && !bytes.Equal(envelope.Payload, []byte("null")) {
+		decoder := json.NewDecoder(bytes.NewReader(envelope.Payload))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(payload); err != nil {
+			return GameRequest{}, &ProtocolError{
+				Code:      CodeInvalidPayload,
+				Request:   envelope.Type,
+				RequestID: envelope.ID,
+				Detail:    fmt.Sprintf("Malformed payload: %v", err),
+			}
+		}
+	}
+
+	if err := payload.Validate(); err != nil {
+		return GameRequest{}, &ProtocolError{
+			Code:      CodeInvalidPayload,
+			Request:   envelope.Type,
+			RequestID: envelope.ID,
+			Detail:    err.Error(),
+		}
+	}
+
+	gameRequest := GameRequest{
+		EventType: envelope.Type,
+		RequestID: envelope.ID,
+		Payload:   payload,
+	}
+
+	return gameRequest, nil
+}
+
+// WithRequestID stamps an answer with the ID of the request it answers
+func WithRequestID(data json.RawMessage, requestID string) (json.RawMessage, error) {
+	return withField(data, "id", requestID)
+}
+
+// WithSeq stamps a broadcast with its number in the room
+func WithSeq(data json.RawMessage, seq uint64) (json.RawMessage, error) {
+	return withField(data, "seq", seq)
+}
+
+func withField(data json.RawMessage, name string, value interface{}) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	field, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	fields[name] = field
+
+	return json.Marshal(fields)
+}
@@ -0,0 +1,108 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/aryuuu/cepex-server/models/game"
+)
+
+func equals(tb testing.TB, exp, act interface{}) {
+	if !reflect.DeepEqual(exp, act) {
+		_, file, line, _ := runtime.Caller(1)
+		fmt.Printf("\033[31m%s:%d:\n\texp: %#v\n\tgot: %#v\033[39m\n", filepath.Base(file), line, exp, act)
+		tb.FailNow()
+	}
+}
+
+func errorCode(err error) string {
+	if protocolErr, ok := err.(*ProtocolError); ok {
+		return protocolErr.Code
+	}
+
+	return ""
+}
+
+func TestDecodeRequest(t *testing.T) {
+	gameRequest, err := DecodeRequest([]byte(`{"v":1,"type":"play-card","id":"42","payload":{"hand_index":1,"is_add":true,"id_target":"p2"}}`))
+	equals(t, nil, err)
+	equals(t, GameRequest{EventType: PlayCardEvent, RequestID: "42", Payload: &PlayCardPayload{HandIndex: 1, IsAdd: true, TargetID: "p2"}}, gameRequest)
+
+	gameRequest, err = DecodeRequest([]byte(`{"v":1,"type":"vote-kick-player","id":"43","payload":{"id_player":"p2","is_yes":true}}`))
+	equals(t, nil, err)
+	equals(t, GameRequest{EventType: VoteKickPlayerEvent, RequestID: "43", Payload: &CastVotePayload{PlayerID: "p2", IsYes: true}}, gameRequest)
+
+	gameRequest, err = DecodeRequest([]byte(`{"v":1,"type":"start-game","id":"44"}`))
+	equals(t, nil, err)
+	equals(t, StartGameEvent, gameRequest.EventType)
+
+	gameRequest, err = DecodeRequest([]byte(`{"v":1,"type":"create-room","id":"45","payload":{"client_name":"a"}}`))
+	equals(t, nil, err)
+	equals(t, 4, gameRequest.Payload.(*CreateRoomPayload).Settings.Capacity)
+}
+
+func TestGameRequestJSON(t *testing.T) {
+	gameRequest := GameRequest{
+		EventType: VoteKickPlayerEvent,
+		RequestID: "43",
+		Payload:   &CastVotePayload{PlayerID: "p2", IsYes: true},
+		Identity:  game.Identity{PlayerID: "p1"},
+	}
+
+	data, err := json.Marshal(gameRequest)
+	equals(t, nil, err)
+
+	var decoded GameRequest
+	equals(t, nil, json.Unmarshal(data, &decoded))
+	equals(t, gameRequest, decoded)
+
+	equals(t, nil, json.Unmarshal([]byte(`{"event_type":"disconnect"}`), &decoded))
+	equals(t, GameRequest{EventType: "disconnect"}, decoded)
+}
+
+func TestDecodeRequestErrors(t *testing.T) {
+	cases := []struct {
+		data string
+		code string
+	}{
+		{`not json`, CodeMalformedRequest},
+		{`{"type":"start-game"}`, CodeUnsupportedVersion},
+		{`{"v":2,"type":"start-game"}`, CodeUnsupportedVersion},
+		{`{"v":1,"type":"start-game"}`, CodeMalformedRequest},
+		{`{"v":1,"type":"disconnect","id":"1"}`, CodeUnknownType},
+		{`{"v":1,"type":"play-card","id":"1","payload":{"hand_index":"one"}}`, CodeInvalidPayload},
+		{`{"v":1,"type":"play-card","id":"1","payload":{"id_player":"p2"}}`, CodeInvalidPayload},
+		{`{"v":1,"type":"play-card","id":"1","payload":{"hand_index":-1}}`, CodeInvalidPayload},
+		{`{"v":1,"type":"join-room","id":"1","payload":{"client_name":" "}}`, CodeInvalidPayload},
+		{`{"v":1,"type":"ban-player","id":"1","payload":{}}`, CodeInvalidPayload},
+		{`{"v":1,"type":"create-room","id":"1","payload":{"client_name":"a","settings":{"capacity":99}}}`, CodeInvalidPayload},
+		{`{"v":1,"type":"add-bot","id":"1","payload":{"strategy":"cheat"}}`, CodeInvalidPayload},
+	}
+
+	for _, c := range cases {
+		_, err := DecodeRequest([]byte(c.data))
+		equals(t, c.code, errorCode(err))
+	}
+
+	_, err := DecodeRequest([]byte(`{"v":1,"type":"chat","id":"7","payload":{}}`))
+	res := err.(*ProtocolError).Response()
+	equals(t, ErrorEvent, res.EventType)
+	equals(t, ChatEvent, res.Request)
+	equals(t, "7", res.RequestID)
+}
+
+func TestWithRequestID(t *testing.T) {
+	data, err := WithRequestID([]byte(`{"event_type":"play-card","success":true}`), "42")
+	equals(t, nil, err)
+	equals(t, `{"event_type":"play-card","id":"42","success":true}`, string(data))
+}
+
+func TestWithSeq(t *testing.T) {
+	data, err := WithSeq([]byte(`{"event_type":"turn-broadcast"}`), 7)
+	equals(t, nil, err)
+	equals(t, `{"event_type":"turn-broadcast","seq":7}`, string(data))
+}
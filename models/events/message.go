@@ -1,52 +1,100 @@
 package events
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/aryuuu/cepex-server/models/game"
-	"github.com/gorilla/websocket"
 )
 
 const (
-	CreateRoomEvent            = "create-room"
-	JoinRoomEvent              = "join-room"
-	JoinRoomBroadcastEvent     = "join-room-broadcast"
-	LeaveRoomEvent             = "leave-room"
-	LeaveRoomBroadcastEvent    = "leave-room-broadcast"
-	KickPlayerEvent            = "kick-player"
-	VoteKickEvent              = "vote-kick"
-	VoteKickPlayerEvent        = "vote-kick-player"
-	VoteKickBroadcastEvent     = "vote-kick-broadcast"
-	StartGameEvent             = "start-game"
-	StartGameBroadcastEvent    = "start-game-broadcast"
-	EndGameBroadcastEvent      = "end-game-broadcast"
-	InitialHandEvent           = "initial-hand"
-	PlayCardEvent              = "play-card"
-	PlayCardBroadcastEvent     = "play-card-broadcast"
-	TurnBroadcastEvent         = "turn-broadcast"
-	DeadPlayerEvent            = "dead-player"
-	ChangeHostBroadcastEvent   = "change-host"
-	ChatEvent                  = "chat"
-	UnicastSocketEvent         = "unicast"
-	BroadcastSocketEvent       = "broadcast"
-	MessageBroadcastEvent      = "message-broadcast"
-	NotificationBroadcastEvent = "notification-broadcast"
+	CreateRoomEvent                  = "create-room"
+	JoinRoomEvent                    = "join-room"
+	JoinRoomBroadcastEvent           = "join-room-broadcast"
+	LeaveRoomEvent                   = "leave-room"
+	LeaveRoomBroadcastEvent          = "leave-room-broadcast"
+	KickPlayerEvent                  = "kick-player"
+	VoteKickEvent                    = "vote-kick"
+	VoteKickPlayerEvent              = "vote-kick-player"
+	VoteKickBroadcastEvent           = "vote-kick-broadcast"
+	StartGameEvent                   = "start-game"
+	StartGameBroadcastEvent          = "start-game-broadcast"
+	EndGameBroadcastEvent            = "end-game-broadcast"
+	InitialHandEvent                 = "initial-hand"
+	PlayCardEvent                    = "play-card"
+	PlayCardBroadcastEvent           = "play-card-broadcast"
+	TurnBroadcastEvent               = "turn-broadcast"
+	DeadPlayerEvent                  = "dead-player"
+	ChangeHostBroadcastEvent         = "change-host"
+	ChatEvent                        = "chat"
+	MessageBroadcastEvent            = "message-broadcast"
+	NotificationBroadcastEvent       = "notification-broadcast"
+	ResumeSessionEvent               = "resume-session"
+	PlayerDisconnectedEvent          = "player-disconnected"
+	PlayerReconnectedEvent           = "player-reconnected"
+	TurnTimerBroadcastEvent          = "turn-timer"
+	PromoteSpectatorEvent            = "promote-spectator"
+	PromoteSpectatorBroadcastEvent   = "promote-spectator-broadcast"
+	UpdateRoomSettingsEvent          = "update-room-settings"
+	UpdateRoomSettingsBroadcastEvent = "update-room-settings-broadcast"
+	LobbyRoomsEvent                  = "lobby-rooms"
+	LobbyUpdateEvent                 = "lobby-update"
+	QuickMatchEvent                  = "quick-match"
+	AddBotEvent                      = "add-bot"
+	RemoveBotEvent                   = "remove-bot"
+	LegalMovesEvent                  = "legal-moves"
+	ReshuffleBroadcastEvent          = "reshuffle"
+	RoundEndBroadcastEvent           = "round-end"
+	MatchEndBroadcastEvent           = "match-end"
+	GetLeaderboardEvent              = "get-leaderboard"
+	LeaderboardBroadcastEvent        = "leaderboard-broadcast"
+	BanPlayerEvent                   = "ban-player"
+	PlayerKickedBroadcastEvent       = "player-kicked"
+	TransferHostEvent                = "transfer-host"
+	LockRoomEvent                    = "lock-room"
+	LockRoomBroadcastEvent           = "lock-room-broadcast"
+	ErrorEvent                       = "error"
+	GetRoomStateEvent                = "get-room-state"
+	SequenceGapEvent                 = "sequence-gap"
+	RoomStateEvent                   = "room-state"
+	VoteKickTallyEvent               = "vote-kick-tally"
+	VoteKickResultEvent              = "vote-kick-result"
 )
 
-type SocketEvent struct {
-	EventType string          `json:"event_type"`
-	RoomID    string          `json:"id_room"`
-	Conn      *websocket.Conn `json:"conn"`
-	Message   interface{}     `json:"message"`
-}
-
+// GameRequest is what a room acts upon, it is decoded from a client request
+// or made up by the server itself. Payload is the typed payload of the
+// request type, requests made up by the server may have none
 type GameRequest struct {
-	EventType  string `json:"event_type,omitempty"`
-	ClientName string `json:"client_name"`
-	AvatarURL  string `json:"avatar_url"`
-	Message    string `json:"message,omitempty"`
-	HandIndex  int    `json:"hand_index,omitempty"`
-	IsAdd      bool   `json:"is_add,omitempty"`
-	PlayerID   string `json:"id_player,omitempty"`
-	IsDiscard  bool   `json:"is_discard"`
+	EventType string  `json:"event_type,omitempty"`
+	RequestID string  `json:"id,omitempty"`
+	Payload   Payload `json:"payload,omitempty"`
+	// Identity is filled in by the server from the ticket the connection
+	// was opened with, whatever the client sends is overwritten
+	Identity game.Identity `json:"identity"`
+}
+
+// UnmarshalJSON decodes the payload into the type registered for the
+// request, so that requests survive being forwarded to another node
+func (r *GameRequest) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		EventType string          `json:"event_type"`
+		RequestID string          `json:"id"`
+		Payload   json.RawMessage `json:"payload"`
+		Identity  game.Identity   `json:"identity"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	r.EventType = raw.EventType
+	r.RequestID = raw.RequestID
+	r.Identity = raw.Identity
+	r.Payload = NewPayload(raw.EventType)
+	if r.Payload != nil && len(raw.Payload) > 0 {
+		return json.Unmarshal(raw.Payload, r.Payload)
+	}
+
+	return nil
 }
 
 type GameResponse struct {
@@ -55,24 +103,27 @@ type GameResponse struct {
 }
 
 type CreateRoomResponse struct {
-	EventType string    `json:"event_type,omitempty"`
-	Success   bool      `json:"success,omitempty"`
-	NewRoom   game.Room `json:"room,omitempty"`
-	Detail    string    `json:"detail,omitempty"`
+	EventType    string    `json:"event_type,omitempty"`
+	Success      bool      `json:"success,omitempty"`
+	NewRoom      game.Room `json:"room,omitempty"`
+	Detail       string    `json:"detail,omitempty"`
+	SessionToken string    `json:"session_token,omitempty"`
 	// Hand      []game.Card `json:"hand"`
 }
 
 type JoinRoomResponse struct {
-	EventType string    `json:"event_type,omitempty"`
-	Success   bool      `json:"success"`
-	NewRoom   game.Room `json:"new_room,omitempty"`
-	Detail    string    `json:"detail,omitempty"`
+	EventType    string    `json:"event_type,omitempty"`
+	Success      bool      `json:"success"`
+	NewRoom      game.Room `json:"new_room,omitempty"`
+	Detail       string    `json:"detail,omitempty"`
+	SessionToken string    `json:"session_token,omitempty"`
 	// Hand      []game.Card `json:"hand"`
 }
 
 type JoinRoomBroadcast struct {
-	EventType string       `json:"event_type,omitempty"`
-	NewPlayer *game.Player `json:"new_player,omitempty"`
+	EventType   string       `json:"event_type,omitempty"`
+	NewPlayer   *game.Player `json:"new_player,omitempty"`
+	IsSpectator bool         `json:"is_spectator"`
 }
 
 type LeaveRoomResponse struct {
@@ -99,12 +150,14 @@ type LeaderboardResponse struct {
 type StartGameBroadcast struct {
 	EventType string `json:"event_type,omitempty"`
 	StarterID string `json:"id_starter"`
+	GameID    string `json:"id_game,omitempty"`
 }
 
 type EndGameBroadcast struct {
 	EventType   string `json:"event_type"`
 	WinnerID    string `json:"id_winner,omitempty"`
 	WinnerScore int    `json:"winner_score"`
+	GameID      string `json:"id_game,omitempty"`
 }
 
 type InitialHandResponse struct {
@@ -164,6 +217,22 @@ type ChangeHostBroadcast struct {
 type VoteKickPlayerResponse struct {
 	EventType string `json:"event_type"`
 	Success   bool   `json:"success"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// VoteKickTallyBroadcast tells where a running vote stands after each vote
+type VoteKickTallyBroadcast struct {
+	EventType string         `json:"event_type"`
+	Tally     game.VoteTally `json:"tally"`
+}
+
+// VoteKickResultBroadcast tells how a vote ended, either passed, failed or
+// expired
+type VoteKickResultBroadcast struct {
+	EventType string         `json:"event_type"`
+	TargetID  string         `json:"id_target"`
+	Result    string         `json:"result"`
+	Tally     game.VoteTally `json:"tally"`
 }
 
 type VoteKickPlayerBroadcast struct {
@@ -172,47 +241,168 @@ type VoteKickPlayerBroadcast struct {
 	IssuerName string `json:"issuer_name"`
 }
 
-func NewUnicastEvent(roomID string, conn *websocket.Conn, message interface{}) SocketEvent {
-	return SocketEvent{
-		EventType: UnicastSocketEvent,
-		RoomID:    roomID,
-		Conn:      conn,
-		Message:   message,
-	}
+type ResumeSessionResponse struct {
+	EventType    string      `json:"event_type"`
+	Success      bool        `json:"success"`
+	PlayerID     string      `json:"id_player,omitempty"`
+	Room         game.Room   `json:"room"`
+	Hand         []game.Card `json:"hand"`
+	Count        int         `json:"count"`
+	TurnID       string      `json:"id_turn"`
+	IsClockwise  bool        `json:"is_clockwise"`
+	SessionToken string      `json:"session_token,omitempty"`
+	Detail       string      `json:"detail,omitempty"`
 }
 
-func NewBroadcastEvent(roomID string, message interface{}) SocketEvent {
-	return SocketEvent{
-		EventType: BroadcastSocketEvent,
-		RoomID:    roomID,
-		Message:   message,
-	}
+type PlayerDisconnectedBroadcast struct {
+	EventType   string `json:"event_type"`
+	PlayerID    string `json:"id_player"`
+	GracePeriod int    `json:"grace_period"`
 }
 
-func NewSocketEvent(eventType, roomID string, conn *websocket.Conn, message interface{}) SocketEvent {
-	return SocketEvent{
-		EventType: eventType,
-		RoomID:    roomID,
-		Message:   message,
-	}
+type TurnTimerBroadcast struct {
+	EventType string    `json:"event_type"`
+	PlayerID  string    `json:"id_player"`
+	Timeout   int       `json:"timeout"`
+	Deadline  time.Time `json:"deadline"`
+}
+
+type PromoteSpectatorResponse struct {
+	EventType string `json:"event_type"`
+	Success   bool   `json:"success"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+type PromoteSpectatorBroadcast struct {
+	EventType string `json:"event_type"`
+	PlayerID  string `json:"id_player"`
+}
+
+// HostActionResponse answers the host after a kick, ban, host transfer or
+// lock
+type HostActionResponse struct {
+	EventType string `json:"event_type"`
+	Success   bool   `json:"success"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+type PlayerKickedBroadcast struct {
+	EventType string `json:"event_type"`
+	PlayerID  string `json:"id_player"`
+	IsBanned  bool   `json:"is_banned"`
+}
+
+// RoomStateResponse is a snapshot of the room, sent on request or when a
+// member has missed a broadcast
+type RoomStateResponse struct {
+	EventType string         `json:"event_type"`
+	State     game.RoomState `json:"state"`
+}
+
+type LockRoomBroadcast struct {
+	EventType string `json:"event_type"`
+	IsLocked  bool   `json:"is_locked"`
+}
+
+// ErrorResponse tells a client why their request was turned down, Code is
+// one of the Code constants for clients to act upon
+type ErrorResponse struct {
+	EventType string `json:"event_type"`
+	Code      string `json:"code"`
+	Request   string `json:"request,omitempty"`
+	RequestID string `json:"id,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+type UpdateRoomSettingsResponse struct {
+	EventType string `json:"event_type"`
+	Success   bool   `json:"success"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+type UpdateRoomSettingsBroadcast struct {
+	EventType string        `json:"event_type"`
+	Settings  game.Settings `json:"settings"`
+	Rules     game.RuleSet  `json:"rules"`
+}
+
+type LobbyRoomsResponse struct {
+	EventType string             `json:"event_type"`
+	Rooms     []game.RoomSummary `json:"rooms"`
+}
+
+type LobbyUpdateBroadcast struct {
+	EventType string           `json:"event_type"`
+	Room      game.RoomSummary `json:"room"`
+	IsListed  bool             `json:"is_listed"`
+}
+
+// QuickMatchResponse points the client to the room it should join, or to
+// create when IsNew is set. The seat, or the room, is held for the client
+// until it presents Reservation or the reservation window is over
+type QuickMatchResponse struct {
+	EventType   string `json:"event_type"`
+	Success     bool   `json:"success"`
+	RoomID      string `json:"id_room,omitempty"`
+	IsNew       bool   `json:"is_new"`
+	Detail      string `json:"detail,omitempty"`
+	Reservation string `json:"reservation,omitempty"`
+}
+
+type AddBotResponse struct {
+	EventType string `json:"event_type"`
+	Success   bool   `json:"success"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+type RemoveBotResponse struct {
+	EventType string `json:"event_type"`
+	Success   bool   `json:"success"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// LegalMovesResponse tells the player whose turn it is what they can play,
+// MustDiscard is set when none of their cards can be played
+type LegalMovesResponse struct {
+	EventType   string      `json:"event_type"`
+	Moves       []game.Move `json:"moves"`
+	MustDiscard bool        `json:"must_discard"`
+}
+
+// ReshuffleBroadcast tells that the discard pile went back into the deck
+type ReshuffleBroadcast struct {
+	EventType string `json:"event_type"`
+	DeckSize  int    `json:"deck_size"`
+}
+
+// RoundEndBroadcast tells how a round of a match went, the next round starts
+// at NextRoundAt
+type RoundEndBroadcast struct {
+	EventType   string          `json:"event_type"`
+	Round       int             `json:"round"`
+	Standings   []game.Standing `json:"standings"`
+	NextRoundAt time.Time       `json:"next_round_at"`
+}
+
+// MatchEndBroadcast tells who won the match after its last round
+type MatchEndBroadcast struct {
+	EventType string          `json:"event_type"`
+	WinnerID  string          `json:"id_winner"`
+	Rounds    int             `json:"rounds"`
+	Standings []game.Standing `json:"standings"`
 }
 
-func NewCreateRoomResponse(success bool, roomID string, host *game.Player, detail string) CreateRoomResponse {
-	players := []*game.Player{host}
+type PlayerReconnectedBroadcast struct {
+	EventType string `json:"event_type"`
+	PlayerID  string `json:"id_player"`
+}
 
+func NewCreateRoomResponse(success bool, room *game.Room, detail string) CreateRoomResponse {
 	result := CreateRoomResponse{
 		EventType: CreateRoomEvent,
 		Success:   success,
-		NewRoom: game.Room{
-			RoomID:      roomID,
-			Capacity:    4,
-			HostID:      host.PlayerID,
-			IsStarted:   false,
-			IsClockwise: false,
-			Players:     players,
-			Count:       0,
-		},
-		Detail: detail,
+		NewRoom:   *room,
+		Detail:    detail,
 	}
 
 	return result
@@ -275,6 +465,24 @@ func NewNotificationBroadcast(message string) NotificationBroadcast {
 	return result
 }
 
+func NewLeaderboardResponse(leaderboard game.Leaderboard) LeaderboardResponse {
+	result := LeaderboardResponse{
+		EventType:   GetLeaderboardEvent,
+		Leaderboard: leaderboard,
+	}
+
+	return result
+}
+
+func NewLeaderboardBroadcast(leaderboard game.Leaderboard) LeaderboardResponse {
+	result := LeaderboardResponse{
+		EventType:   LeaderboardBroadcastEvent,
+		Leaderboard: leaderboard,
+	}
+
+	return result
+}
+
 func NewStartGameResponse(success bool) StartGameResponse {
 	result := StartGameResponse{
 		EventType: StartGameEvent,
@@ -362,10 +570,35 @@ func NewChangeHostBroadcast(hostID string) ChangeHostBroadcast {
 	}
 }
 
-func NewVoteKickPlayerResponse(success bool) VoteKickPlayerResponse {
+func NewVoteKickPlayerResponse(success bool, detail string) VoteKickPlayerResponse {
 	return VoteKickPlayerResponse{
 		EventType: VoteKickEvent,
 		Success:   success,
+		Detail:    detail,
+	}
+}
+
+func NewCastVoteResponse(success bool, detail string) VoteKickPlayerResponse {
+	return VoteKickPlayerResponse{
+		EventType: VoteKickPlayerEvent,
+		Success:   success,
+		Detail:    detail,
+	}
+}
+
+func NewVoteKickTallyBroadcast(tally game.VoteTally) VoteKickTallyBroadcast {
+	return VoteKickTallyBroadcast{
+		EventType: VoteKickTallyEvent,
+		Tally:     tally,
+	}
+}
+
+func NewVoteKickResultBroadcast(result string, tally game.VoteTally) VoteKickResultBroadcast {
+	return VoteKickResultBroadcast{
+		EventType: VoteKickResultEvent,
+		TargetID:  tally.TargetID,
+		Result:    result,
+		Tally:     tally,
 	}
 }
 
@@ -376,3 +609,192 @@ func NewVoteKickPlayerBroadcast(targetID, issuerName string) VoteKickPlayerBroad
 		IssuerName: issuerName,
 	}
 }
+
+func NewResumeSessionResponse(success bool, room *game.Room, player *game.Player, detail string) ResumeSessionResponse {
+	result := ResumeSessionResponse{
+		EventType: ResumeSessionEvent,
+		Success:   success,
+		Room:      *room,
+		Detail:    detail,
+	}
+
+	if player != nil {
+		result.PlayerID = player.PlayerID
+		result.Hand = player.Hand
+		result.Count = room.Count
+		result.TurnID = room.TurnID
+		result.IsClockwise = room.IsClockwise
+	}
+
+	return result
+}
+
+func NewPlayerDisconnectedBroadcast(playerID string, gracePeriod int) PlayerDisconnectedBroadcast {
+	return PlayerDisconnectedBroadcast{
+		EventType:   PlayerDisconnectedEvent,
+		PlayerID:    playerID,
+		GracePeriod: gracePeriod,
+	}
+}
+
+func NewPlayerReconnectedBroadcast(playerID string) PlayerReconnectedBroadcast {
+	return PlayerReconnectedBroadcast{
+		EventType: PlayerReconnectedEvent,
+		PlayerID:  playerID,
+	}
+}
+
+func NewTurnTimerBroadcast(playerID string, timeout int, deadline time.Time) TurnTimerBroadcast {
+	return TurnTimerBroadcast{
+		EventType: TurnTimerBroadcastEvent,
+		PlayerID:  playerID,
+		Timeout:   timeout,
+		Deadline:  deadline,
+	}
+}
+
+func NewHostActionResponse(eventType string, success bool, detail string) HostActionResponse {
+	return HostActionResponse{
+		EventType: eventType,
+		Success:   success,
+		Detail:    detail,
+	}
+}
+
+func NewPlayerKickedBroadcast(playerID string, isBanned bool) PlayerKickedBroadcast {
+	return PlayerKickedBroadcast{
+		EventType: PlayerKickedBroadcastEvent,
+		PlayerID:  playerID,
+		IsBanned:  isBanned,
+	}
+}
+
+func NewLockRoomBroadcast(isLocked bool) LockRoomBroadcast {
+	return LockRoomBroadcast{
+		EventType: LockRoomBroadcastEvent,
+		IsLocked:  isLocked,
+	}
+}
+
+func NewRoomStateResponse(state game.RoomState) RoomStateResponse {
+	return RoomStateResponse{
+		EventType: RoomStateEvent,
+		State:     state,
+	}
+}
+
+func NewErrorResponse(code, request, requestID, detail string) ErrorResponse {
+	return ErrorResponse{
+		EventType: ErrorEvent,
+		Code:      code,
+		Request:   request,
+		RequestID: requestID,
+		Detail:    detail,
+	}
+}
+
+func NewPromoteSpectatorResponse(success bool, detail string) PromoteSpectatorResponse {
+	return PromoteSpectatorResponse{
+		EventType: PromoteSpectatorEvent,
+		Success:   success,
+		Detail:    detail,
+	}
+}
+
+func NewPromoteSpectatorBroadcast(playerID string) PromoteSpectatorBroadcast {
+	return PromoteSpectatorBroadcast{
+		EventType: PromoteSpectatorBroadcastEvent,
+		PlayerID:  playerID,
+	}
+}
+
+func NewUpdateRoomSettingsResponse(success bool, detail string) UpdateRoomSettingsResponse {
+	return UpdateRoomSettingsResponse{
+		EventType: UpdateRoomSettingsEvent,
+		Success:   success,
+		Detail:    detail,
+	}
+}
+
+func NewUpdateRoomSettingsBroadcast(room *game.Room) UpdateRoomSettingsBroadcast {
+	return UpdateRoomSettingsBroadcast{
+		EventType: UpdateRoomSettingsBroadcastEvent,
+		Settings:  room.Settings,
+		Rules:     room.Rules,
+	}
+}
+
+func NewLobbyRoomsResponse(rooms []game.RoomSummary) LobbyRoomsResponse {
+	return LobbyRoomsResponse{
+		EventType: LobbyRoomsEvent,
+		Rooms:     rooms,
+	}
+}
+
+func NewLobbyUpdateBroadcast(room game.RoomSummary, isListed bool) LobbyUpdateBroadcast {
+	return LobbyUpdateBroadcast{
+		EventType: LobbyUpdateEvent,
+		Room:      room,
+		IsListed:  isListed,
+	}
+}
+
+func NewQuickMatchResponse(success bool, roomID string, isNew bool, reservation, detail string) QuickMatchResponse {
+	return QuickMatchResponse{
+		EventType:   QuickMatchEvent,
+		Success:     success,
+		RoomID:      roomID,
+		IsNew:       isNew,
+		Reservation: reservation,
+		Detail:      detail,
+	}
+}
+
+func NewAddBotResponse(success bool, detail string) AddBotResponse {
+	return AddBotResponse{
+		EventType: AddBotEvent,
+		Success:   success,
+		Detail:    detail,
+	}
+}
+
+func NewRemoveBotResponse(success bool, detail string) RemoveBotResponse {
+	return RemoveBotResponse{
+		EventType: RemoveBotEvent,
+		Success:   success,
+		Detail:    detail,
+	}
+}
+
+func NewLegalMovesResponse(moves []game.Move) LegalMovesResponse {
+	return LegalMovesResponse{
+		EventType:   LegalMovesEvent,
+		Moves:       moves,
+		MustDiscard: len(moves) == 0,
+	}
+}
+
+func NewReshuffleBroadcast(deckSize int) ReshuffleBroadcast {
+	return ReshuffleBroadcast{
+		EventType: ReshuffleBroadcastEvent,
+		DeckSize:  deckSize,
+	}
+}
+
+func NewRoundEndBroadcast(round int, standings []game.Standing, nextRoundAt time.Time) RoundEndBroadcast {
+	return RoundEndBroadcast{
+		EventType:   RoundEndBroadcastEvent,
+		Round:       round,
+		Standings:   standings,
+		NextRoundAt: nextRoundAt,
+	}
+}
+
+func NewMatchEndBroadcast(match *game.Match, standings []game.Standing) MatchEndBroadcast {
+	return MatchEndBroadcast{
+		EventType: MatchEndBroadcastEvent,
+		WinnerID:  match.WinnerID,
+		Rounds:    match.Round,
+		Standings: standings,
+	}
+}
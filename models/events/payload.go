@@ -0,0 +1,232 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aryuuu/cepex-server/models/game"
+)
+
+const (
+	maxNameLength    = 32
+	maxMessageLength = 500
+)
+
+// Payload is what a client sends along with a request of a given type
+type Payload interface {
+	// Validate checks the payload before it reaches the room
+	Validate() error
+}
+
+// registry lists the requests clients are allowed to make, along with the
+// payload each of them carries
+var registry = map[string]func() Payload{
+	CreateRoomEvent:         func() Payload { return &CreateRoomPayload{} },
+	JoinRoomEvent:           func() Payload { return &JoinRoomPayload{} },
+	ResumeSessionEvent:      func() Payload { return &ResumeSessionPayload{} },
+	LeaveRoomEvent:          func() Payload { return &EmptyPayload{} },
+	KickPlayerEvent:         func() Payload { return &KickPlayerPayload{} },
+	BanPlayerEvent:          func() Payload { return &TargetPayload{} },
+	TransferHostEvent:       func() Payload { return &TargetPayload{} },
+	LockRoomEvent:           func() Payload { return &LockRoomPayload{} },
+	VoteKickEvent:           func() Payload { return &TargetPayload{} },
+	VoteKickPlayerEvent:     func() Payload { return &CastVotePayload{} },
+	StartGameEvent:          func() Payload { return &EmptyPayload{} },
+	PlayCardEvent:           func() Payload { return &PlayCardPayload{} },
+	PromoteSpectatorEvent:   func() Payload { return &TargetPayload{} },
+	UpdateRoomSettingsEvent: func() Payload { return &UpdateRoomSettingsPayload{} },
+	AddBotEvent:             func() Payload { return &AddBotPayload{} },
+	RemoveBotEvent:          func() Payload { return &TargetPayload{} },
+	GetLeaderboardEvent:     func() Payload { return &EmptyPayload{} },
+	GetRoomStateEvent:       func() Payload { return &EmptyPayload{} },
+	SequenceGapEvent:        func() Payload { return &SequenceGapPayload{} },
+	ChatEvent:               func() Payload { return &ChatPayload{} },
+	QuickMatchEvent:         func() Payload { return &EmptyPayload{} },
+}
+
+// NewPayload returns an empty payload of the given request type, nil when
+// clients may not make such requests
+func NewPayload(eventType string) Payload {
+	newPayload, ok := registry[eventType]
+	if !ok {
+		return nil
+	}
+
+	return newPayload()
+}
+
+// EmptyPayload is carried by requests that need nothing but their type
+type EmptyPayload struct{}
+
+func (p *EmptyPayload) Validate() error {
+	return nil
+}
+
+// CreateRoomPayload sets up a new room, Reservation is the token quick
+// match handed out when it reserved the room
+type CreateRoomPayload struct {
+	ClientName  string        `json:"client_name"`
+	AvatarURL   string        `json:"avatar_url"`
+	Settings    game.Settings `json:"settings"`
+	Reservation string        `json:"reservation,omitempty"`
+}
+
+func (p *CreateRoomPayload) Validate() error {
+	if err := validateName(p.ClientName); err != nil {
+		return err
+	}
+
+	return p.Settings.Validate()
+}
+
+// JoinRoomPayload takes a seat in the room, Reservation is the token quick
+// match handed out when it reserved the seat
+type JoinRoomPayload struct {
+	ClientName  string `json:"client_name"`
+	AvatarURL   string `json:"avatar_url"`
+	AsSpectator bool   `json:"as_spectator"`
+	Password    string `json:"password,omitempty"`
+	Reservation string `json:"reservation,omitempty"`
+}
+
+func (p *JoinRoomPayload) Validate() error {
+	return validateName(p.ClientName)
+}
+
+type ResumeSessionPayload struct {
+	SessionToken string `json:"session_token"`
+}
+
+func (p *ResumeSessionPayload) Validate() error {
+	if p.SessionToken == "" {
+		return errors.New("session_token is required")
+	}
+
+	return nil
+}
+
+// KickPlayerPayload names who the host kicks, leaving it empty is how one
+// leaves the room
+type KickPlayerPayload struct {
+	PlayerID string `json:"id_player,omitempty"`
+}
+
+func (p *KickPlayerPayload) Validate() error {
+	return nil
+}
+
+// TargetPayload is carried by requests aimed at a single member of the room
+type TargetPayload struct {
+	PlayerID string `json:"id_player"`
+}
+
+func (p *TargetPayload) Validate() error {
+	if p.PlayerID == "" {
+		return errors.New("id_player is required")
+	}
+
+	return nil
+}
+
+type LockRoomPayload struct {
+	IsLocked bool `json:"is_locked"`
+}
+
+func (p *LockRoomPayload) Validate() error {
+	return nil
+}
+
+// CastVotePayload is a vote on kicking the target, IsYes tells whether the
+// voter wants them out
+type CastVotePayload struct {
+	PlayerID string `json:"id_player"`
+	IsYes    bool   `json:"is_yes"`
+}
+
+func (p *CastVotePayload) Validate() error {
+	if p.PlayerID == "" {
+		return errors.New("id_player is required")
+	}
+
+	return nil
+}
+
+// PlayCardPayload is a move, TargetID names who a targeted card is aimed at
+type PlayCardPayload struct {
+	HandIndex int    `json:"hand_index"`
+	IsAdd     bool   `json:"is_add"`
+	TargetID  string `json:"id_target,omitempty"`
+	IsDiscard bool   `json:"is_discard"`
+}
+
+// Move is the move the payload asks for
+func (p *PlayCardPayload) Move() game.Move {
+	return game.Move{
+		HandIndex: p.HandIndex,
+		IsAdd:     p.IsAdd,
+		TargetID:  p.TargetID,
+		IsDiscard: p.IsDiscard,
+	}
+}
+
+func (p *PlayCardPayload) Validate() error {
+	if p.HandIndex < 0 {
+		return errors.New("hand_index should not be negative")
+	}
+
+	return nil
+}
+
+type UpdateRoomSettingsPayload struct {
+	Settings game.Settings `json:"settings"`
+}
+
+func (p *UpdateRoomSettingsPayload) Validate() error {
+	return p.Settings.Validate()
+}
+
+type AddBotPayload struct {
+	Strategy string `json:"strategy,omitempty"`
+}
+
+func (p *AddBotPayload) Validate() error {
+	_, err := game.NewStrategy(p.Strategy)
+	return err
+}
+
+// SequenceGapPayload reports a missed broadcast, LastSeq is the number of
+// the last broadcast the member got in order
+type SequenceGapPayload struct {
+	LastSeq uint64 `json:"last_seq"`
+}
+
+func (p *SequenceGapPayload) Validate() error {
+	return nil
+}
+
+type ChatPayload struct {
+	Message string `json:"message"`
+}
+
+func (p *ChatPayload) Validate() error {
+	if strings.TrimSpace(p.Message) == "" {
+		return errors.New("message is required")
+	}
+	if len(p.Message) > maxMessageLength {
+		return fmt.Errorf("message should not be longer than %v characters", maxMessageLength)
+	}
+
+	return nil
+}
+
+func validateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("client_name is required")
+	}
+	if len(name) > maxNameLength {
+		return fmt.Errorf("client_name should not be longer than %v characters", maxNameLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package game
+
+// Broker fans room traffic out to every node serving the game
+type Broker interface {
+	Publish(topic string, message []byte) error
+	// Subscribe calls the handler for every message published to the topic
+	// until the returned unsubscribe function is called
+	Subscribe(topic string, handler func(message []byte)) (unsubscribe func(), err error)
+	// Claim makes the node the owner of a room unless it is already owned by
+	// another node, the current owner is returned. Owners have to keep
+	// claiming their rooms for the ownership not to lapse
+	Claim(roomID, nodeID string) (owner string, err error)
+	Release(roomID, nodeID string) error
+}
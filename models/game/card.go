@@ -2,9 +2,10 @@ package game
 
 import (
 	"math/rand"
-	"time"
 )
 
+const cardsPerDeck = 52
+
 // Card :nodoc:
 type Card struct {
 	// 0 = diamond
@@ -15,25 +16,28 @@ type Card struct {
 	Rank    int `json:"rank"`
 }
 
+// IsSpecial tells whether the card does anything but adding its rank under
+// the classic rules, rooms may play by other rules
 func (c Card) IsSpecial() bool {
-	return c.Rank == 1 || c.Rank == 4 || c.Rank == 7 || c.Rank == 11 || c.Rank == 12 || c.Rank == 13
+	return NewClassicRuleSet().IsSpecial(c)
 }
 
-func NewDeck() []Card {
-	totalCard := 52
-	result := make([]Card, totalCard)
+// NewDeck shuffles the given number of 52-card decks together
+func NewDeck(decks int, rng *rand.Rand) []Card {
+	result := make([]Card, 0, cardsPerDeck*decks)
 
-	for pattern := 0; pattern < 4; pattern++ {
-		for rank := 1; rank < 14; rank++ {
-			result[(13*pattern)+rank-1] = Card{
-				Rank:    rank,
-				Pattern: pattern,
+	for deck := 0; deck < decks; deck++ {
+		for pattern := 0; pattern < 4; pattern++ {
+			for rank := 1; rank < 14; rank++ {
+				result = append(result, Card{
+					Rank:    rank,
+					Pattern: pattern,
+				})
 			}
 		}
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(result), func(i, j int) { result[i], result[j] = result[j], result[i] })
+	rng.Shuffle(len(result), func(i, j int) { result[i], result[j] = result[j], result[i] })
 
 	return result
 }
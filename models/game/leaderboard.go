@@ -1,12 +1,72 @@
 package game
 
+import "sort"
+
 type Leaderboard struct {
 	Items []LeaderboardItem `json:"items"`
 }
 
+// LeaderboardItem is how a player has fared in the games of a room, Score
+// counts the games won
 type LeaderboardItem struct {
-	PlayerID  string `json:"id_player,omitempty"`
-	Name      string `json:"name,omitempty"`
-	AvatarURL string `json:"avatar_url,omitempty"`
-	Score     int    `json:"score,omitempty"`
+	PlayerID      string  `json:"id_player,omitempty"`
+	Name          string  `json:"name,omitempty"`
+	AvatarURL     string  `json:"avatar_url,omitempty"`
+	Score         int     `json:"score,omitempty"`
+	GamesPlayed   int     `json:"games_played"`
+	Eliminations  int     `json:"eliminations"`
+	FinishTotal   int     `json:"finish_total"`
+	AverageFinish float64 `json:"average_finish"`
+}
+
+// addFinish records a game the player finished in the given position,
+// first being the winner
+func (li *LeaderboardItem) addFinish(position int) {
+	li.GamesPlayed++
+	li.FinishTotal += position
+	li.AverageFinish = float64(li.FinishTotal) / float64(li.GamesPlayed)
+
+	if position == 1 {
+		li.Score++
+	} else {
+		li.Eliminations++
+	}
+}
+
+// recordResults adds the ranking of the game that just ended to the
+// leaderboard of the room
+func (r *Room) recordResults() {
+	for i, playerID := range r.Ranking {
+		player := r.Finisher(playerID)
+
+		item := r.Leaderboard[playerID]
+		item.PlayerID = player.PlayerID
+		item.Name = player.Name
+		item.AvatarURL = player.AvatarURL
+		item.addFinish(i + 1)
+
+		r.Leaderboard[playerID] = item
+	}
+}
+
+// GetLeaderboard ranks everyone who has finished a game in the room, most
+// wins first and better average finish breaking ties
+func (r *Room) GetLeaderboard() Leaderboard {
+	result := Leaderboard{Items: []LeaderboardItem{}}
+	for _, item := range r.Leaderboard {
+		result.Items = append(result.Items, item)
+	}
+
+	sort.Slice(result.Items, func(i, j int) bool {
+		a, b := result.Items[i], result.Items[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.AverageFinish != b.AverageFinish {
+			return a.AverageFinish < b.AverageFinish
+		}
+		return a.PlayerID < b.PlayerID
+	})
+
+	return result
 }
@@ -0,0 +1,41 @@
+package game
+
+import "testing"
+
+func TestLeaderboard(t *testing.T) {
+	players := []*Player{NewPlayer("player1", ""), NewPlayer("player2", ""), NewPlayer("player3", "")}
+	room := NewRoom("1", players[0].PlayerID, 3)
+	for _, p := range players {
+		room.AddPlayer(p)
+	}
+
+	room.StartGameWithSeed(1)
+	room.Eliminate(players[2].PlayerID)
+	room.Eliminate(players[1].PlayerID)
+
+	room.StartGameWithSeed(2)
+	room.Eliminate(players[0].PlayerID)
+	room.Eliminate(players[2].PlayerID)
+
+	room.StartGameWithSeed(3)
+	room.Eliminate(players[2].PlayerID)
+	room.Eliminate(players[1].PlayerID)
+
+	items := room.GetLeaderboard().Items
+	equals(t, 3, len(items))
+
+	equals(t, players[0].PlayerID, items[0].PlayerID)
+	equals(t, 2, items[0].Score)
+	equals(t, 3, items[0].GamesPlayed)
+	equals(t, 1, items[0].Eliminations)
+	equals(t, 5.0/3, items[0].AverageFinish)
+
+	equals(t, players[1].PlayerID, items[1].PlayerID)
+	equals(t, 1, items[1].Score)
+	equals(t, 5.0/3, items[1].AverageFinish)
+
+	equals(t, players[2].PlayerID, items[2].PlayerID)
+	equals(t, 0, items[2].Score)
+	equals(t, 3, items[2].Eliminations)
+	equals(t, 8.0/3, items[2].AverageFinish)
+}
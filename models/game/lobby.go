@@ -0,0 +1,121 @@
+package game
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReservationWindow is how long a seat found by quick match is held
+const ReservationWindow = 30 * time.Second
+
+var ErrNoSeat = errors.New("There is no seat left to reserve")
+
+// RoomSummary is what the lobby tells about a room
+type RoomSummary struct {
+	RoomID         string   `json:"id_room"`
+	HostName       string   `json:"host_name"`
+	PlayerCount    int      `json:"player_count"`
+	SpectatorCount int      `json:"spectator_count"`
+	IsStarted      bool     `json:"is_started"`
+	IsLocked       bool     `json:"is_locked"`
+	Settings       Settings `json:"settings"`
+	// ReservedSeats are held for players quick match has sent to the room
+	ReservedSeats int `json:"reserved_seats"`
+}
+
+func NewRoomSummary(room *Room) RoomSummary {
+	result := RoomSummary{
+		RoomID:         room.RoomID,
+		PlayerCount:    len(room.Players),
+		SpectatorCount: len(room.Spectators),
+		IsStarted:      room.IsStarted,
+		IsLocked:       room.IsLocked,
+		Settings:       room.Settings,
+		ReservedSeats:  room.ReservedSeats(),
+	}
+
+	if host := room.PlayerMap[room.HostID]; host != nil {
+		result.HostName = host.Name
+	}
+
+	return result
+}
+
+// IsListed tells whether the room shows up in the public lobby, that is a
+// public room waiting for its next game
+func (s RoomSummary) IsListed() bool {
+	return !s.Settings.IsPrivate && !s.IsStarted
+}
+
+// IsOpen tells whether anyone can take a seat in the room right away
+func (s RoomSummary) IsOpen() bool {
+	return s.IsListed() && !s.IsLocked && !s.Settings.HasPassword && s.PlayerCount+s.ReservedSeats < s.Settings.Capacity
+}
+
+// QuickMatchCandidates lists the rooms anyone can take a seat in, fullest
+// first
+func QuickMatchCandidates(rooms []RoomSummary) []string {
+	open := []RoomSummary{}
+	for _, room := range rooms {
+		if room.IsOpen() {
+			open = append(open, room)
+		}
+	}
+
+	sort.SliceStable(open, func(i, j int) bool {
+		return open[i].PlayerCount+open[i].ReservedSeats > open[j].PlayerCount+open[j].ReservedSeats
+	})
+
+	result := []string{}
+	for _, room := range open {
+		result = append(result, room.RoomID)
+	}
+
+	return result
+}
+
+// ReserveSeat holds a seat for whoever quick match sends to the room, the
+// token returned lets them take it before the reservation window is over
+func (r *Room) ReserveSeat() (string, error) {
+	if r.IsStarted || r.IsLocked || r.Settings.IsPrivate || r.Settings.HasPassword || r.IsFull() {
+		return "", ErrNoSeat
+	}
+
+	token := uuid.NewString()
+	r.Reservations[token] = time.Now().UTC().Add(ReservationWindow)
+
+	return token, nil
+}
+
+// HoldsReservation tells whether the token still holds a seat
+func (r *Room) HoldsReservation(token string) bool {
+	r.pruneReservations()
+	_, ok := r.Reservations[token]
+
+	return ok
+}
+
+// ClaimReservation gives up the reservation of a token, once its holder has
+// taken the seat
+func (r *Room) ClaimReservation(token string) {
+	delete(r.Reservations, token)
+}
+
+// ReservedSeats counts the seats held for someone who is yet to join
+func (r *Room) ReservedSeats() int {
+	r.pruneReservations()
+
+	return len(r.Reservations)
+}
+
+func (r *Room) pruneReservations() {
+	now := time.Now()
+	for token, expiresAt := range r.Reservations {
+		if now.After(expiresAt) {
+			delete(r.Reservations, token)
+		}
+	}
+}
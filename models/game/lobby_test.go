@@ -0,0 +1,56 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuickMatchCandidates(t *testing.T) {
+	settings := NewSettings()
+	private := NewSettings()
+	private.IsPrivate = true
+	locked := NewSettings()
+	locked.HasPassword = true
+
+	rooms := []RoomSummary{
+		{RoomID: "A", PlayerCount: 1, Settings: settings},
+		{RoomID: "B", PlayerCount: 3, Settings: settings},
+		{RoomID: "C", PlayerCount: 4, Settings: settings},
+		{RoomID: "D", PlayerCount: 3, Settings: private},
+		{RoomID: "E", PlayerCount: 3, Settings: locked},
+		{RoomID: "F", PlayerCount: 3, Settings: settings, IsStarted: true},
+		{RoomID: "G", PlayerCount: 2, Settings: settings, ReservedSeats: 2},
+		{RoomID: "H", PlayerCount: 1, Settings: settings, ReservedSeats: 1},
+	}
+
+	equals(t, []string{"B", "H", "A"}, QuickMatchCandidates(rooms))
+	equals(t, []string{}, QuickMatchCandidates(rooms[2:6]))
+}
+
+func TestReserveSeat(t *testing.T) {
+	room := NewRoom("1", "fatt", 2)
+	room.AddPlayer(NewPlayer("player1", ""))
+
+	token, err := room.ReserveSeat()
+	equals(t, nil, err)
+	equals(t, true, room.IsFull())
+	equals(t, 1, NewRoomSummary(room).ReservedSeats)
+
+	_, err = room.ReserveSeat()
+	equals(t, ErrNoSeat, err)
+
+	equals(t, true, room.HoldsReservation(token))
+	equals(t, false, room.HoldsReservation("forged"))
+
+	room.Reservations[token] = time.Now().Add(-time.Second)
+	equals(t, false, room.HoldsReservation(token))
+	equals(t, false, room.IsFull())
+
+	token, _ = room.ReserveSeat()
+	room.ClaimReservation(token)
+	equals(t, 0, room.ReservedSeats())
+
+	room.IsLocked = true
+	_, err = room.ReserveSeat()
+	equals(t, ErrNoSeat, err)
+}
@@ -0,0 +1,103 @@
+package game
+
+import "sort"
+
+// Match keeps the scores of a series of games played in a room. After every
+// round each player scores a point for every player they outlasted
+type Match struct {
+	Round    int            `json:"round"`
+	Scores   map[string]int `json:"scores"`
+	WinnerID string         `json:"id_winner,omitempty"`
+}
+
+// Standing is where a player stands in a match
+type Standing struct {
+	PlayerID    string `json:"id_player"`
+	Name        string `json:"name"`
+	RoundPoints int    `json:"round_points"`
+	Score       int    `json:"score"`
+}
+
+func NewMatch() *Match {
+	return &Match{
+		Round:  0,
+		Scores: make(map[string]int),
+	}
+}
+
+// AddRound scores the ranking of a finished game, winner first. It returns
+// the points everyone scored in the round
+func (m *Match) AddRound(ranking []string) map[string]int {
+	points := make(map[string]int)
+	for i, playerID := range ranking {
+		points[playerID] = len(ranking) - 1 - i
+		m.Scores[playerID] += points[playerID]
+	}
+
+	return points
+}
+
+// Finish tells whether the match is over under the settings of the room,
+// that is when the target score or the number of rounds has been reached and
+// there is a single leader among the players still in the room. Tied leaders
+// keep on playing
+func (m *Match) Finish(room *Room) bool {
+	settings := room.Settings
+	best := m.best(room)
+	isDone := (settings.TargetScore > 0 && best >= settings.TargetScore) ||
+		(settings.Rounds > 0 && m.Round >= settings.Rounds)
+	if !isDone {
+		return false
+	}
+
+	leaders := []string{}
+	for _, p := range room.Players {
+		if score, ok := m.Scores[p.PlayerID]; ok && score == best {
+			leaders = append(leaders, p.PlayerID)
+		}
+	}
+
+	if len(leaders) != 1 {
+		return false
+	}
+
+	m.WinnerID = leaders[0]
+	return true
+}
+
+// Standings ranks the players still in the room by their score
+func (m *Match) Standings(room *Room, points map[string]int) []Standing {
+	result := []Standing{}
+	for _, p := range room.Players {
+		score, ok := m.Scores[p.PlayerID]
+		if !ok {
+			continue
+		}
+
+		result = append(result, Standing{
+			PlayerID:    p.PlayerID,
+			Name:        p.Name,
+			RoundPoints: points[p.PlayerID],
+			Score:       score,
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Score > result[j].Score
+	})
+
+	return result
+}
+
+// best is the highest score of the players still in the room, those who
+// left can no longer lead the match
+func (m *Match) best(room *Room) int {
+	best := 0
+	for _, p := range room.Players {
+		if score := m.Scores[p.PlayerID]; score > best {
+			best = score
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,113 @@
+package game
+
+import "testing"
+
+func newMatchRoom(settings Settings, playerIDs ...string) *Room {
+	room := NewRoom("1", playerIDs[0], len(playerIDs))
+	room.Settings = settings
+	for _, playerID := range playerIDs {
+		player := NewPlayer(playerID, "")
+		player.PlayerID = playerID
+		room.AddPlayer(player)
+	}
+
+	return room
+}
+
+func TestMatchTargetScore(t *testing.T) {
+	room := newMatchRoom(Settings{TargetScore: 3}, "a", "b", "c")
+	match := NewMatch()
+
+	match.Round++
+	points := match.AddRound([]string{"a", "b", "c"})
+	equals(t, map[string]int{"a": 2, "b": 1, "c": 0}, points)
+	equals(t, false, match.Finish(room))
+
+	match.Round++
+	match.AddRound([]string{"b", "a", "c"})
+	equals(t, false, match.Finish(room))
+	equals(t, "", match.WinnerID)
+
+	match.Round++
+	match.AddRound([]string{"c", "b", "a"})
+	equals(t, true, match.Finish(room))
+	equals(t, "b", match.WinnerID)
+}
+
+func TestMatchRounds(t *testing.T) {
+	room := newMatchRoom(Settings{Rounds: 1}, "a", "b")
+	match := NewMatch()
+
+	match.Round++
+	match.AddRound([]string{"a", "b"})
+	equals(t, true, match.Finish(room))
+	equals(t, "a", match.WinnerID)
+}
+
+func TestMatchTie(t *testing.T) {
+	room := newMatchRoom(Settings{Rounds: 2}, "a", "b")
+	match := NewMatch()
+
+	match.Round++
+	match.AddRound([]string{"a", "b"})
+	match.Round++
+	match.AddRound([]string{"b", "a"})
+	equals(t, false, match.Finish(room))
+}
+
+func TestMatchLeaderLeaves(t *testing.T) {
+	room := newMatchRoom(Settings{Rounds: 2}, "a", "b", "c")
+	match := NewMatch()
+
+	match.Round++
+	match.AddRound([]string{"a", "b", "c"})
+	match.Round++
+	match.AddRound([]string{"b", "a", "c"})
+	equals(t, false, match.Finish(room))
+
+	room.Leave("a")
+	equals(t, true, match.Finish(room))
+	equals(t, "b", match.WinnerID)
+
+	room = newMatchRoom(Settings{TargetScore: 2}, "a", "b", "c")
+	match = NewMatch()
+	match.Round++
+	match.AddRound([]string{"a", "b", "c"})
+	room.Leave("a")
+	equals(t, false, match.Finish(room))
+	equals(t, "", match.WinnerID)
+}
+
+func TestRanking(t *testing.T) {
+	players := []*Player{NewPlayer("player1", ""), NewPlayer("player2", ""), NewPlayer("player3", "")}
+	room := NewRoom("1", players[0].PlayerID, 3)
+	for _, p := range players {
+		room.AddPlayer(p)
+	}
+	room.StartGameWithSeed(1)
+
+	room.Eliminate(players[1].PlayerID)
+	room.Eliminate(players[0].PlayerID)
+
+	equals(t, false, room.IsStarted)
+	equals(t, []string{players[2].PlayerID, players[0].PlayerID, players[1].PlayerID}, room.Ranking)
+}
+
+func TestRankingLeaver(t *testing.T) {
+	players := []*Player{NewPlayer("player1", ""), NewPlayer("player2", ""), NewPlayer("player3", "")}
+	players[1].AccountID = "account2"
+	room := NewRoom("1", players[0].PlayerID, 3)
+	for _, p := range players {
+		room.AddPlayer(p)
+	}
+	room.StartGameWithSeed(1)
+
+	room.Leave(players[1].PlayerID)
+	equals(t, true, room.IsStarted)
+	room.Eliminate(players[0].PlayerID)
+
+	equals(t, false, room.IsStarted)
+	equals(t, []string{players[2].PlayerID, players[0].PlayerID, players[1].PlayerID}, room.Ranking)
+	equals(t, "account2", room.Finisher(players[1].PlayerID).AccountID)
+	equals(t, 3, room.Leaderboard[players[1].PlayerID].FinishTotal)
+}
@@ -0,0 +1,70 @@
+package game
+
+// Move :nodoc:
+type Move struct {
+	HandIndex int    `json:"hand_index"`
+	IsAdd     bool   `json:"is_add"`
+	TargetID  string `json:"id_target,omitempty"`
+	IsDiscard bool   `json:"is_discard"`
+}
+
+// TurnResult is what came out of a player's turn
+type TurnResult struct {
+	Card         Card
+	IsPlayed     bool
+	IsDead       bool
+	Winner       *Player
+	NextPlayerID string
+	// IsReshuffled tells whether the discard pile went back into the deck
+	IsReshuffled bool
+}
+
+// LeaveResult is what came out of a player leaving the room
+type LeaveResult struct {
+	IsRemoved    bool
+	NewHostID    string
+	NextPlayerID string
+	Winner       *Player
+}
+
+// LegalMoves lists every move the player could make with their hand, an
+// empty list means the only way out is to discard
+func (r *Room) LegalMoves(playerID string) []Move {
+	result := []Move{}
+
+	player := r.PlayerMap[playerID]
+	if !r.IsStarted || player == nil || !player.IsAlive {
+		return result
+	}
+
+	for i, card := range player.Hand {
+		effect := r.Rules.Effect(card.Rank).Effect
+		if effect == TargetEffect {
+			for _, p := range r.Players {
+				if r.isValidTarget(playerID, p.PlayerID) {
+					result = append(result, Move{HandIndex: i, IsAdd: true, TargetID: p.PlayerID})
+				}
+			}
+			continue
+		}
+
+		choices := []bool{true}
+		if effect == ChoiceEffect {
+			choices = append(choices, false)
+		}
+
+		for _, isAdd := range choices {
+			if _, _, err := r.cardEffect(card, isAdd); err == nil {
+				result = append(result, Move{HandIndex: i, IsAdd: isAdd})
+			}
+		}
+	}
+
+	return result
+}
+
+// isValidTarget tells whether a player can hand the turn over to the target
+func (r *Room) isValidTarget(playerID, targetID string) bool {
+	target := r.PlayerMap[targetID]
+	return target != nil && target.IsAlive && targetID != playerID
+}
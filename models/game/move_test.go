@@ -0,0 +1,58 @@
+package game
+
+import "testing"
+
+func TestLegalMoves(t *testing.T) {
+	player1 := NewPlayer("player1", "")
+	player2 := NewPlayer("player2", "")
+	player3 := NewPlayer("player3", "")
+	room := NewRoom("1", player1.PlayerID, 3)
+	room.AddPlayer(player1)
+	room.AddPlayer(player2)
+	room.AddPlayer(player3)
+
+	equals(t, []Move{}, room.LegalMoves(player1.PlayerID))
+
+	room.StartGameWithSeed(1)
+	player3.IsAlive = false
+
+	room.Count = 95
+	player1.Hand = []Card{{Rank: 12}, {Rank: 7}, {Rank: 6}}
+	equals(t, []Move{
+		{HandIndex: 0, IsAdd: false},
+		{HandIndex: 1, IsAdd: true, TargetID: player2.PlayerID},
+	}, room.LegalMoves(player1.PlayerID))
+
+	room.Count = 0
+	player1.Hand = []Card{{Rank: 1}, {Rank: 4}}
+	equals(t, []Move{
+		{HandIndex: 0, IsAdd: true},
+		{HandIndex: 1, IsAdd: true},
+	}, room.LegalMoves(player1.PlayerID))
+
+	room.Count = 99
+	player1.Hand = []Card{{Rank: 5}, {Rank: 9}}
+	equals(t, []Move{}, room.LegalMoves(player1.PlayerID))
+	equals(t, []Move{}, room.LegalMoves(player3.PlayerID))
+}
+
+func TestPlayInvalidTarget(t *testing.T) {
+	player1 := NewPlayer("player1", "")
+	player2 := NewPlayer("player2", "")
+	room := NewRoom("1", player1.PlayerID, 2)
+	room.AddPlayer(player1)
+	room.AddPlayer(player2)
+	room.StartGameWithSeed(1)
+	room.TurnID = player1.PlayerID
+
+	player1.Hand = []Card{{Rank: 7}, {Rank: 7}}
+	_, err := room.Play(player1.PlayerID, Move{HandIndex: 0, TargetID: ""})
+	assert(t, err != nil, "Playing a 7 without a target should be rejected")
+	_, err = room.Play(player1.PlayerID, Move{HandIndex: 0, TargetID: player1.PlayerID})
+	assert(t, err != nil, "Playing a 7 on oneself should be rejected")
+	equals(t, 2, len(player1.Hand))
+
+	result, err := room.Play(player1.PlayerID, Move{HandIndex: 0, TargetID: player2.PlayerID})
+	equals(t, nil, err)
+	equals(t, player2.PlayerID, result.NextPlayerID)
+}
@@ -0,0 +1,98 @@
+package game
+
+import "errors"
+
+var (
+	ErrNotHost        = errors.New("Only the host can do this")
+	ErrNotPlayer      = errors.New("Only seated players can do this")
+	ErrNotMember      = errors.New("Join the room first")
+	ErrRoomLocked     = errors.New("Room is locked")
+	ErrBanned         = errors.New("You are banned from this room")
+	ErrPlayerNotFound = errors.New("Player not found")
+	ErrCannotBeHost   = errors.New("Only a seated human can be the host")
+)
+
+// Role is what a member of a room is allowed to do, every role may do what
+// the roles below it may
+type Role int
+
+const (
+	// GuestRole is anyone who has not joined the room
+	GuestRole Role = iota
+	SpectatorRole
+	PlayerRole
+	HostRole
+)
+
+// Ban keeps someone out of a room by any of the ways they could be told
+// apart
+type Ban struct {
+	PlayerID  string `json:"id_player"`
+	AccountID string `json:"id_account,omitempty"`
+	IP        string `json:"ip,omitempty"`
+}
+
+// RoleOf tells what the member is to the room
+func (r *Room) RoleOf(playerID string) Role {
+	switch {
+	case playerID == "":
+		return GuestRole
+	case playerID == r.HostID && r.PlayerMap[playerID] != nil:
+		return HostRole
+	case r.PlayerMap[playerID] != nil:
+		return PlayerRole
+	case r.GetSpectator(playerID) != nil:
+		return SpectatorRole
+	default:
+		return GuestRole
+	}
+}
+
+// Authorize checks that the member is at least of the given role
+func (r *Room) Authorize(playerID string, role Role) error {
+	if r.RoleOf(playerID) >= role {
+		return nil
+	}
+
+	switch role {
+	case HostRole:
+		return ErrNotHost
+	case PlayerRole:
+		return ErrNotPlayer
+	default:
+		return ErrNotMember
+	}
+}
+
+// TransferHost hands the room over to another seated human
+func (r *Room) TransferHost(playerID string) error {
+	player := r.PlayerMap[playerID]
+	if player == nil || player.IsBot {
+		return ErrCannotBeHost
+	}
+
+	r.HostID = playerID
+	return nil
+}
+
+// Ban keeps the member out of the room, they still have to be removed
+func (r *Room) Ban(player *Player) {
+	r.Bans = append(r.Bans, Ban{
+		PlayerID:  player.PlayerID,
+		AccountID: player.AccountID,
+		IP:        player.IP,
+	})
+}
+
+// IsBanned tells whether the identity has been banned from the room
+func (r *Room) IsBanned(identity Identity) bool {
+	for _, ban := range r.Bans {
+		if ban.PlayerID == identity.PlayerID ||
+			(ban.AccountID != "" && ban.AccountID == identity.AccountID) ||
+			(ban.IP != "" && ban.IP == identity.IP) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,51 @@
+package game
+
+import "testing"
+
+func TestRoleOf(t *testing.T) {
+	host := NewPlayer("host", "")
+	player := NewPlayer("player", "")
+	spectator := NewPlayer("spectator", "")
+	room := NewRoom("1", host.PlayerID, 3)
+	room.AddPlayer(host)
+	room.AddPlayer(player)
+	room.AddSpectator(spectator)
+
+	equals(t, HostRole, room.RoleOf(host.PlayerID))
+	equals(t, PlayerRole, room.RoleOf(player.PlayerID))
+	equals(t, SpectatorRole, room.RoleOf(spectator.PlayerID))
+	equals(t, GuestRole, room.RoleOf("stranger"))
+
+	equals(t, nil, room.Authorize(host.PlayerID, PlayerRole))
+	equals(t, ErrNotHost, room.Authorize(player.PlayerID, HostRole))
+	equals(t, ErrNotPlayer, room.Authorize(spectator.PlayerID, PlayerRole))
+	equals(t, ErrNotMember, room.Authorize("stranger", SpectatorRole))
+}
+
+func TestTransferHost(t *testing.T) {
+	host := NewPlayer("host", "")
+	player := NewPlayer("player", "")
+	bot := NewBot("bot", RandomStrategy)
+	room := NewRoom("1", host.PlayerID, 3)
+	room.AddPlayer(host)
+	room.AddPlayer(player)
+	room.AddPlayer(bot)
+
+	equals(t, ErrCannotBeHost, room.TransferHost(bot.PlayerID))
+	equals(t, nil, room.TransferHost(player.PlayerID))
+	equals(t, HostRole, room.RoleOf(player.PlayerID))
+	equals(t, PlayerRole, room.RoleOf(host.PlayerID))
+}
+
+func TestBan(t *testing.T) {
+	player := NewPlayer("player", "")
+	player.AccountID = "account"
+	player.IP = "10.0.0.1"
+	room := NewRoom("1", "host", 3)
+	room.Ban(player)
+
+	equals(t, true, room.IsBanned(Identity{PlayerID: player.PlayerID}))
+	equals(t, true, room.IsBanned(Identity{PlayerID: "another", AccountID: "account"}))
+	equals(t, true, room.IsBanned(Identity{PlayerID: "another", IP: "10.0.0.1"}))
+	equals(t, false, room.IsBanned(Identity{PlayerID: "another", IP: "10.0.0.2"}))
+}
@@ -8,36 +8,46 @@ import (
 
 // Player :nodoc:
 type Player struct {
-	PlayerID  string `json:"id_player,omitempty"`
-	Name      string `json:"name,omitempty"`
-	AvatarURL string `json:"avatar_url"`
-	IsAlive   bool   `json:"is_alive"`
-	Score     int    `json:"score"`
-	Hand      []Card `json:"-"`
+	PlayerID    string `json:"id_player,omitempty"`
+	Name        string `json:"name,omitempty"`
+	AvatarURL   string `json:"avatar_url"`
+	IsAlive     bool   `json:"is_alive"`
+	IsConnected bool   `json:"is_connected"`
+	Score       int    `json:"score"`
+	IsBot       bool   `json:"is_bot"`
+	Strategy    string `json:"strategy,omitempty"`
+	AccountID   string `json:"id_account,omitempty"`
+	IP          string `json:"-"`
+	Hand        []Card `json:"-"`
 }
 
 func NewPlayer(name, avatarUrl string) *Player {
 	return &Player{
-		Name:      name,
-		AvatarURL: avatarUrl,
-		PlayerID:  uuid.NewString(),
-		IsAlive:   false,
-		Hand:      []Card{},
+		Name:        name,
+		AvatarURL:   avatarUrl,
+		PlayerID:    uuid.NewString(),
+		IsAlive:     false,
+		IsConnected: true,
+		Hand:        []Card{},
 	}
 }
 
+// NewBot seats a player whose moves are decided by the named strategy
+func NewBot(name, strategy string) *Player {
+	bot := NewPlayer(name, "")
+	bot.IsBot = true
+	bot.Strategy = strategy
+
+	return bot
+}
+
 func (p *Player) PlayHand(index int) (card Card, err error) {
 	if index >= len(p.Hand) {
 		err = errors.New("card is unavailable")
 		return
 	}
 	card = p.Hand[index]
-
-	if index == 0 {
-		p.Hand = p.Hand[1:]
-	} else {
-		p.Hand = p.Hand[:1]
-	}
+	p.Hand = append(p.Hand[:index:index], p.Hand[index+1:]...)
 
 	return
 }
@@ -48,11 +58,13 @@ func (p *Player) AddHand(card []Card) {
 }
 
 func (p *Player) InsertHand(card Card, index int) {
-	if index == 0 {
-		p.Hand = append([]Card{card}, p.Hand...)
-	} else {
-		p.Hand = append(p.Hand, card)
+	if index > len(p.Hand) {
+		index = len(p.Hand)
 	}
+
+	hand := append([]Card{}, p.Hand[:index]...)
+	hand = append(hand, card)
+	p.Hand = append(hand, p.Hand[index:]...)
 }
 
 func (p *Player) Win() {
@@ -0,0 +1,31 @@
+package game
+
+import "math/rand"
+
+// countingSource keeps track of how many numbers have been drawn from it, so
+// a random source can be brought back to the exact same point later on
+type countingSource struct {
+	source rand.Source64
+	draws  uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{
+		source: rand.NewSource(seed).(rand.Source64),
+	}
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.source.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.source.Uint64()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.source.Seed(seed)
+	s.draws = 0
+}
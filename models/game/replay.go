@@ -0,0 +1,168 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	CommandEntry   = "command"
+	BroadcastEntry = "broadcast"
+
+	PlayCardAction     = "play-card"
+	ForceDiscardAction = "force-discard"
+	EliminateAction    = "eliminate"
+	LeaveAction        = "leave"
+)
+
+var ErrReplayNotFound = errors.New("replay not found")
+
+// ReplayRepository keeps the logs of finished games, and the log of the game
+// running in a room apart from the room so that it grows an entry at a time
+type ReplayRepository interface {
+	Get(roomID, gameID string) (*GameLog, error)
+	// Save stores a finished game and drops the running log of its room
+	Save(gameLog *GameLog) error
+	Begin(gameLog *GameLog) error
+	Append(roomID string, entry LogEntry) error
+	Running(roomID string) (*GameLog, error)
+	Discard(roomID string) error
+}
+
+// GameLog is everything that happened during a single game, in order
+type GameLog struct {
+	GameID    string      `json:"id_game"`
+	RoomID    string      `json:"id_room"`
+	Seed      int64       `json:"seed"`
+	Settings  Settings    `json:"settings"`
+	Players   []LogPlayer `json:"players"`
+	StartedAt time.Time   `json:"started_at"`
+	EndedAt   time.Time   `json:"ended_at"`
+	Entries   []LogEntry  `json:"entries"`
+}
+
+// LogPlayer is a seat as it was when the game started
+type LogPlayer struct {
+	PlayerID  string `json:"id_player"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// LogEntry is either a command accepted by the room or a broadcast sent out
+// because of it
+type LogEntry struct {
+	Sequence  int             `json:"sequence"`
+	Timestamp time.Time       `json:"timestamp"`
+	Kind      string          `json:"kind"`
+	Type      string          `json:"type"`
+	PlayerID  string          `json:"id_player,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+// NewGameLog starts the log of a game that has just been started in the room
+func NewGameLog(gameID string, room *Room) *GameLog {
+	result := &GameLog{
+		GameID:    gameID,
+		RoomID:    room.RoomID,
+		Seed:      room.Seed,
+		Settings:  room.Settings,
+		Players:   []LogPlayer{},
+		StartedAt: time.Now(),
+		Entries:   []LogEntry{},
+	}
+
+	for _, p := range room.Players {
+		result.Players = append(result.Players, LogPlayer{
+			PlayerID:  p.PlayerID,
+			Name:      p.Name,
+			AvatarURL: p.AvatarURL,
+		})
+	}
+
+	return result
+}
+
+// AddCommand records a command the room has accepted
+func (l *GameLog) AddCommand(action, playerID string, payload interface{}) (LogEntry, error) {
+	return l.add(CommandEntry, action, playerID, payload)
+}
+
+// AddBroadcast records a message sent to the whole room
+func (l *GameLog) AddBroadcast(eventType string, payload json.RawMessage) (LogEntry, error) {
+	return l.add(BroadcastEntry, eventType, "", payload)
+}
+
+func (l *GameLog) add(kind, entryType, playerID string, payload interface{}) (LogEntry, error) {
+	var data json.RawMessage
+	if payload != nil {
+		var err error
+		data, err = json.Marshal(payload)
+		if err != nil {
+			return LogEntry{}, err
+		}
+	}
+
+	entry := LogEntry{
+		Sequence:  len(l.Entries),
+		Timestamp: time.Now(),
+		Kind:      kind,
+		Type:      entryType,
+		PlayerID:  playerID,
+		Payload:   data,
+	}
+	l.Entries = append(l.Entries, entry)
+
+	return entry, nil
+}
+
+// Replay rebuilds the room as it was after the given number of commands of
+// the log, a negative number replays the whole game
+func Replay(gameLog *GameLog, moves int) (*Room, error) {
+	room := NewRoom(gameLog.RoomID, "", len(gameLog.Players))
+	if err := room.UpdateSettings(gameLog.Settings); err != nil {
+		return nil, err
+	}
+	for _, p := range gameLog.Players {
+		player := NewPlayer(p.Name, p.AvatarURL)
+		player.PlayerID = p.PlayerID
+		room.AddPlayer(player)
+	}
+
+	room.StartGameWithSeed(gameLog.Seed)
+
+	played := 0
+	for _, entry := range gameLog.Entries {
+		if entry.Kind != CommandEntry {
+			continue
+		}
+		if moves >= 0 && played >= moves {
+			break
+		}
+		played++
+
+		switch entry.Type {
+		case PlayCardAction:
+			var move Move
+			if err := json.Unmarshal(entry.Payload, &move); err != nil {
+				return nil, fmt.Errorf("entry %v: %v", entry.Sequence, err)
+			}
+			if _, err := room.Play(entry.PlayerID, move); err != nil {
+				return nil, fmt.Errorf("entry %v: %v", entry.Sequence, err)
+			}
+		case ForceDiscardAction:
+			if _, err := room.ForceDiscard(entry.PlayerID); err != nil {
+				return nil, fmt.Errorf("entry %v: %v", entry.Sequence, err)
+			}
+		case EliminateAction:
+			room.Eliminate(entry.PlayerID)
+		case LeaveAction:
+			room.Leave(entry.PlayerID)
+		default:
+			return nil, fmt.Errorf("entry %v: unknown command %v", entry.Sequence, entry.Type)
+		}
+	}
+
+	return room, nil
+}
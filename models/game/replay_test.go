@@ -0,0 +1,77 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// playLoggedGame plays a whole game through Play where everyone discards
+// their first card, recording every move in a log
+func playLoggedGame(seed int64) (*Room, *GameLog, []int) {
+	room := NewRoom("1", "fatt", 3)
+	room.AddPlayer(NewPlayer("player1", ""))
+	room.AddPlayer(NewPlayer("player2", ""))
+	room.AddPlayer(NewPlayer("player3", ""))
+	room.StartGameWithSeed(seed)
+	gameLog := NewGameLog("game1", room)
+
+	counts := []int{}
+	for room.IsStarted {
+		playerID := room.TurnID
+		move := Move{HandIndex: 0, IsAdd: true, IsDiscard: true}
+		if _, err := room.Play(playerID, move); err != nil {
+			break
+		}
+
+		gameLog.AddCommand(PlayCardAction, playerID, move)
+		counts = append(counts, room.Count)
+	}
+
+	return room, gameLog, counts
+}
+
+func TestReplay(t *testing.T) {
+	room, gameLog, counts := playLoggedGame(42)
+
+	data, err := json.Marshal(gameLog)
+	equals(t, nil, err)
+	var decoded GameLog
+	equals(t, nil, json.Unmarshal(data, &decoded))
+
+	replayed, err := Replay(&decoded, -1)
+	equals(t, nil, err)
+	equals(t, false, replayed.IsStarted)
+	for i, p := range room.Players {
+		equals(t, p.PlayerID, replayed.Players[i].PlayerID)
+		equals(t, p.Score, replayed.Players[i].Score)
+	}
+
+	for move := 1; move <= len(counts); move++ {
+		replayed, err := Replay(&decoded, move)
+		equals(t, nil, err)
+		if replayed.IsStarted {
+			equals(t, counts[move-1], replayed.Count)
+		}
+	}
+}
+
+func TestReplayLeave(t *testing.T) {
+	room := NewRoom("1", "fatt", 3)
+	room.AddPlayer(NewPlayer("player1", ""))
+	room.AddPlayer(NewPlayer("player2", ""))
+	room.StartGameWithSeed(7)
+	gameLog := NewGameLog("game1", room)
+
+	leaverID := room.TurnID
+	result := room.Leave(leaverID)
+	gameLog.AddCommand(LeaveAction, leaverID, nil)
+
+	assert(t, result.Winner != nil, "the last player standing should win")
+	equals(t, false, room.IsStarted)
+
+	replayed, err := Replay(gameLog, -1)
+	equals(t, nil, err)
+	equals(t, 1, len(replayed.Players))
+	equals(t, result.Winner.PlayerID, replayed.Players[0].PlayerID)
+	equals(t, 1, replayed.Players[0].Score)
+}
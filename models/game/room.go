@@ -1,6 +1,7 @@
 package game
 
 import (
+	"crypto/subtle"
 	"errors"
 	"math/rand"
 	"time"
@@ -9,52 +10,211 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+var (
+	ErrRoomNotFound    = errors.New("room not found")
+	ErrGameNotStarted  = errors.New("Game is not started")
+	ErrNotYourTurn     = errors.New("Please wait for your turn")
+	ErrPlayerDead      = errors.New("You are already dead")
+	ErrCardUnavailable = errors.New("Card is unavailable")
+	ErrGameStarted     = errors.New("Game has already started")
+	ErrRoomFull        = errors.New("Room is full")
+	ErrTooManyPlayers  = errors.New("There are more players than the capacity")
+	ErrNotSpectator    = errors.New("Player is not a spectator")
+)
+
 type GameUsecase interface {
-	Connect(conn *websocket.Conn, roomID string)
-	RunSwitch()
+	Connect(conn *websocket.Conn, roomID string, identity Identity)
+	IssueTicket(roomID, sessionToken, accountID string) (*Ticket, error)
+	VerifyTicket(roomID, ticket string) (Identity, error)
+	GetReplay(roomID, gameID string) (*GameLog, error)
+	ListRooms() ([]RoomSummary, error)
+	ConnectLobby(conn *websocket.Conn)
+}
+
+// RoomRepository :nodoc:
+type RoomRepository interface {
+	Get(roomID string) (*Room, error)
+	List() ([]*Room, error)
+	Save(room *Room) error
+	Delete(roomID string) error
 }
 
 // Room :nodoc:
 type Room struct {
-	RoomID      string                     `json:"id_room,omitempty"`
-	Capacity    int                        `json:"capacity,omitempty"`
-	HostID      string                     `json:"id_host,omitempty"`
-	IsStarted   bool                       `json:"is_started,omitempty"`
-	IsClockwise bool                       `json:"is_clockwise,omitempty"`
-	Players     []*Player                  `json:"players,omitempty"`
-	PlayerMap   map[string]*Player         `json:"-"`
-	Deck        []Card                     `json:"-"`
-	TurnID      string                     `json:"id_turn"`
-	Count       int                        `json:"count"`
-	VoteBallot  map[string]int             `json:"-"`
+	RoomID      string             `json:"id_room,omitempty"`
+	Capacity    int                `json:"capacity,omitempty"`
+	HostID      string             `json:"id_host,omitempty"`
+	IsStarted   bool               `json:"is_started,omitempty"`
+	IsClockwise bool               `json:"is_clockwise,omitempty"`
+	Players     []*Player          `json:"players,omitempty"`
+	Spectators  []*Player          `json:"spectators,omitempty"`
+	PlayerMap   map[string]*Player `json:"-"`
+	Deck        []Card             `json:"-"`
+	DiscardPile []Card             `json:"-"`
+	TurnID      string             `json:"id_turn"`
+	Count       int                `json:"count"`
+	Settings    Settings           `json:"settings"`
+	Rules       RuleSet            `json:"rules"`
+	Match       *Match             `json:"match,omitempty"`
+	IsLocked    bool               `json:"is_locked"`
+	// Seq is the number of the last broadcast sent to the room, members
+	// missing one can tell from the gap
+	Seq         uint64                     `json:"seq"`
+	Password    string                     `json:"-"`
+	VoteBallot  map[string]*VoteKick       `json:"-"`
 	Leaderboard map[string]LeaderboardItem `json:"-"`
+	// Bans keeps whoever the host has banned out for the lifetime of the
+	// room
+	Bans []Ban `json:"-"`
+	// Reservations holds the seats handed out by quick match until their
+	// holders join, each token maps to when it runs out
+	Reservations map[string]time.Time `json:"-"`
+	// GameLog records the game being played, it is nil between games
+	GameLog *GameLog `json:"-"`
+	// Eliminations lists the players who died in the current game, first
+	// to die first. Leaving counts as dying
+	Eliminations []string `json:"-"`
+	// Leavers keeps the players who left the last game before it ended, so
+	// that they are still ranked
+	Leavers map[string]*Player `json:"-"`
+	// Ranking is how the players of the last game finished, winner first
+	Ranking []string `json:"-"`
+	// Seed is what the random source of the current game has been seeded
+	// with, replaying a game with the same seed yields the same shuffles
+	Seed   int64 `json:"-"`
+	rng    *rand.Rand
+	source *countingSource
+	// reshuffles counts how many times the discard pile went back into the
+	// deck, it tells callers whether a move caused one
+	reshuffles int
 }
 
 func NewRoom(id, host string, capacity int) *Room {
-	return &Room{
-		RoomID:      id,
-		Capacity:    capacity,
-		HostID:      host,
-		IsStarted:   false,
-		IsClockwise: false,
-		Players:     []*Player{},
-		PlayerMap:   make(map[string]*Player),
-		Deck:        NewDeck(),
-		Count:       0,
-		VoteBallot:  make(map[string]int),
-		Leaderboard: make(map[string]LeaderboardItem),
+	room := &Room{
+		RoomID:       id,
+		Capacity:     capacity,
+		HostID:       host,
+		IsStarted:    false,
+		IsClockwise:  false,
+		Players:      []*Player{},
+		PlayerMap:    make(map[string]*Player),
+		Count:        0,
+		Settings:     NewSettings(),
+		Rules:        NewClassicRuleSet(),
+		VoteBallot:   make(map[string]*VoteKick),
+		Leaderboard:  make(map[string]LeaderboardItem),
+		Leavers:      make(map[string]*Player),
+		Reservations: make(map[string]time.Time),
+	}
+	room.Settings.Capacity = capacity
+	room.SetSeed(time.Now().UnixNano())
+	room.Deck = NewDeck(room.deckCount(), room.rng)
+
+	return room
+}
+
+// UpdateSettings replaces the settings of the room, they can only be changed
+// between games
+func (r *Room) UpdateSettings(settings Settings) error {
+	if r.IsStarted {
+		return ErrGameStarted
+	}
+
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	if len(r.Players) > settings.Capacity {
+		return ErrTooManyPlayers
+	}
+
+	rules, err := NewRuleSet(settings)
+	if err != nil {
+		return err
+	}
+	r.Rules = rules
+
+	if !settings.IsMatch() {
+		r.Match = nil
+	}
+
+	r.Password = settings.Password
+	settings.Password = ""
+	settings.HasPassword = r.Password != ""
+
+	r.Capacity = settings.Capacity
+	r.Settings = settings
+
+	return nil
+}
+
+// CheckPassword tells whether the password lets someone into the room
+func (r *Room) CheckPassword(password string) bool {
+	return subtle.ConstantTimeCompare([]byte(r.Password), []byte(password)) == 1
+}
+
+// IsFull tells whether every seat is either taken or reserved
+func (r *Room) IsFull() bool {
+	return len(r.Players)+r.ReservedSeats() >= r.Capacity
+}
+
+// SetSeed replaces the random source of the room with one seeded with the
+// given seed
+func (r *Room) SetSeed(seed int64) {
+	r.Seed = seed
+	r.source = newCountingSource(seed)
+	r.rng = rand.New(r.source)
+}
+
+// Draws tells how many numbers have been drawn from the random source since
+// it was seeded
+func (r *Room) Draws() uint64 {
+	if r.source == nil {
+		return 0
+	}
+
+	return r.source.draws
+}
+
+// RestoreRandom brings the random source back to where it was after the
+// given number of draws
+func (r *Room) RestoreRandom(seed int64, draws uint64) {
+	r.SetSeed(seed)
+	for i := uint64(0); i < draws; i++ {
+		r.source.Int63()
 	}
 }
 
+// random returns the random source of the room, a room brought back from
+// storage gets one seeded with its recorded seed
+func (r *Room) random() *rand.Rand {
+	if r.rng == nil {
+		r.SetSeed(r.Seed)
+	}
+
+	return r.rng
+}
+
 func (r *Room) StartGame() string {
+	return r.StartGameWithSeed(time.Now().UnixNano())
+}
+
+// StartGameWithSeed starts a game whose deck and turn order are drawn from a
+// random source seeded with the given seed
+func (r *Room) StartGameWithSeed(seed int64) string {
+	r.SetSeed(seed)
 	r.IsStarted = true
+	r.Deck = NewDeck(r.deckCount(), r.rng)
+	r.DiscardPile = []Card{}
+	r.Eliminations = []string{}
+	r.Leavers = make(map[string]*Player)
 
 	for _, player := range r.Players {
 		player.IsAlive = true
-		player.Hand = append(player.Hand, r.PickCard(2)...)
+		player.Hand = append(player.Hand, r.PickCard(r.handSize())...)
 	}
 
-	starterIndex := rand.Intn(len(r.Players))
+	starterIndex := r.rng.Intn(len(r.Players))
 	r.TurnID = r.Players[starterIndex].PlayerID
 
 	return r.TurnID
@@ -64,35 +224,64 @@ func (r *Room) EndGame(winnerID string) {
 	r.Count = 0
 	r.IsStarted = false
 	r.IsClockwise = false
-	r.Deck = NewDeck()
+	r.Deck = NewDeck(r.deckCount(), r.random())
+	r.DiscardPile = []Card{}
 	r.PlayerMap[winnerID].Win()
 
+	r.Ranking = []string{winnerID}
+	for i := len(r.Eliminations) - 1; i >= 0; i-- {
+		if r.Finisher(r.Eliminations[i]) != nil {
+			r.Ranking = append(r.Ranking, r.Eliminations[i])
+		}
+	}
+	r.Eliminations = []string{}
+	r.recordResults()
+
 	for _, p := range r.Players {
 		p.IsAlive = false
 		p.Hand = []Card{}
 	}
 }
 
+// PickCard draws n cards, the discard pile is shuffled back into the deck
+// whenever the deck runs out. Fewer cards are drawn only when both are empty
 func (r *Room) PickCard(n int) []Card {
-	if len(r.Deck) < n {
-		return nil
-	}
+	result := []Card{}
+
+	for len(result) < n {
+		if len(r.Deck) == 0 && !r.reshuffle() {
+			break
+		}
+
+		take := n - len(result)
+		if take > len(r.Deck) {
+			take = len(r.Deck)
+		}
 
-	result := r.Deck[:n]
-	r.Deck = r.Deck[n:]
+		result = append(result, r.Deck[:take]...)
+		r.Deck = r.Deck[take:]
+	}
 
 	return result
 }
 
-func (r *Room) PutCard(cards []Card) {
-	rand.Seed(time.Now().UnixNano())
-	randomNumbers := rand.Perm(len(r.Deck))
-
-	for idx, randomNumber := range randomNumbers[:len(cards)] {
-		temp := r.Deck[randomNumber]
-		r.Deck[randomNumber] = cards[idx]
-		r.Deck = append(r.Deck, temp)
+// reshuffle turns the discard pile into a new deck
+func (r *Room) reshuffle() bool {
+	if len(r.DiscardPile) == 0 {
+		return false
 	}
+
+	r.Deck = append(r.Deck, r.DiscardPile...)
+	r.DiscardPile = []Card{}
+	r.random().Shuffle(len(r.Deck), func(i, j int) { r.Deck[i], r.Deck[j] = r.Deck[j], r.Deck[i] })
+	r.reshuffles++
+
+	return true
+}
+
+// Discard puts played cards onto the discard pile
+func (r *Room) Discard(cards ...Card) {
+	r.DiscardPile = append(r.DiscardPile, cards...)
 }
 
 func (r *Room) PlayCard(playerID string, handIndex int, isAdd bool, targetID string) error {
@@ -102,55 +291,58 @@ func (r *Room) PlayCard(playerID string, handIndex int, isAdd bool, targetID str
 		return err
 	}
 
-	factor := 1
-	if !isAdd {
-		factor = -1
-	}
-
-	if !card.IsSpecial() {
-		if r.Count+card.Rank <= 100 {
-			r.Count += card.Rank
-		} else {
-			return errors.New("Card is unplayable")
+	if r.Rules.Effect(card.Rank).Effect == TargetEffect {
+		if !r.isValidTarget(playerID, targetID) {
+			return errors.New("invalid target")
 		}
+		r.TurnID = targetID
 	} else {
-		switch card.Rank {
-		case 1:
-			if r.Count+(factor*1) > 100 || r.Count+(factor*1) < 0 {
-				return errors.New("invalid move")
-			}
-			r.Count += factor * 1
-		case 4:
-			r.IsClockwise = !r.IsClockwise
-		case 7:
-			if target := r.PlayerMap[targetID]; target != nil && !target.IsAlive {
-				return errors.New("target is dead")
-			}
-			r.TurnID = targetID
-		case 11:
-			if r.Count+(factor*10) > 100 || r.Count+(factor*10) < 0 {
-				return errors.New("invalid move")
-			}
-			r.Count += factor * 10
-		case 12:
-			if r.Count+(factor*20) > 100 || r.Count+(factor*20) < 0 {
-				return errors.New("invalid move")
-			}
-			r.Count += factor * 20
-		case 13:
-			r.Count = 100
-		default:
-			break
+		count, isClockwise, err := r.cardEffect(card, isAdd)
+		if err != nil {
+			return err
 		}
-
+		r.Count = count
+		r.IsClockwise = isClockwise
 	}
 
+	r.Discard(card)
 	player.AddHand(r.PickCard(1))
-	r.PutCard([]Card{card})
 
 	return nil
 }
 
+// cardEffect works out the count and the direction a card would leave the
+// room with, without touching the room
+func (r *Room) cardEffect(card Card, isAdd bool) (count int, isClockwise bool, err error) {
+	count = r.Count
+	isClockwise = r.IsClockwise
+
+	effect := r.Rules.Effect(card.Rank)
+	switch effect.Effect {
+	case AddEffect:
+		if count+card.Rank > r.Rules.Limit {
+			return r.Count, r.IsClockwise, errors.New("Card is unplayable")
+		}
+		count += card.Rank
+	case ChoiceEffect:
+		if isAdd {
+			count += effect.Value
+		} else {
+			count -= effect.Value
+		}
+	case ReverseEffect:
+		isClockwise = !isClockwise
+	case LimitEffect:
+		count = r.Rules.Limit
+	}
+
+	if count > r.Rules.Limit || count < 0 {
+		return r.Count, r.IsClockwise, errors.New("invalid move")
+	}
+
+	return count, isClockwise, nil
+}
+
 func (r *Room) NextPlayer(playerIndex int) string {
 	var nextPlayerIndex int
 	if r.IsClockwise {
@@ -181,7 +373,7 @@ func (r *Room) NextPlayer(playerIndex int) string {
 
 func (r *Room) NextHost() (newHostID string) {
 	for _, p := range r.Players {
-		if p.PlayerID != r.HostID {
+		if p.PlayerID != r.HostID && !p.IsBot {
 			r.HostID = p.PlayerID
 			newHostID = p.PlayerID
 			return
@@ -191,15 +383,237 @@ func (r *Room) NextHost() (newHostID string) {
 	return
 }
 
+// Play makes a player take their turn. A card that can not be played goes
+// back to the hand unless the move asks for it to be discarded
+func (r *Room) Play(playerID string, move Move) (result TurnResult, err error) {
+	if !r.IsStarted {
+		return result, ErrGameNotStarted
+	}
+
+	if r.TurnID != playerID {
+		return result, ErrNotYourTurn
+	}
+
+	player := r.PlayerMap[playerID]
+	if !player.IsAlive {
+		return result, ErrPlayerDead
+	}
+
+	if move.HandIndex < 0 || move.HandIndex >= len(player.Hand) {
+		return result, ErrCardUnavailable
+	}
+
+	playerIndex := r.GetPlayerIndex(playerID)
+	card := player.Hand[move.HandIndex]
+	reshuffles := r.reshuffles
+
+	if err := r.PlayCard(playerID, move.HandIndex, move.IsAdd, move.TargetID); err != nil {
+		if !move.IsDiscard {
+			player.InsertHand(card, move.HandIndex)
+			return result, err
+		}
+		r.Discard(card)
+	} else {
+		result.Card = card
+		result.IsPlayed = true
+	}
+
+	result.IsReshuffled = r.reshuffles != reshuffles
+	isSkip := result.IsPlayed && r.Rules.Effect(card.Rank).Effect == SkipEffect
+
+	return r.endTurn(player, playerIndex, isSkip, result), nil
+}
+
+// ForceDiscard throws away the first card of the player whose turn it is
+// without playing it, e.g. for running out of time. A new card is drawn and
+// the turn moves on
+func (r *Room) ForceDiscard(playerID string) (result TurnResult, err error) {
+	if !r.IsStarted {
+		return result, ErrGameNotStarted
+	}
+
+	if r.TurnID != playerID {
+		return result, ErrNotYourTurn
+	}
+
+	player := r.PlayerMap[playerID]
+	if !player.IsAlive {
+		return result, ErrPlayerDead
+	}
+
+	playerIndex := r.GetPlayerIndex(playerID)
+	reshuffles := r.reshuffles
+
+	card, err := player.PlayHand(0)
+	if err != nil {
+		return result, ErrCardUnavailable
+	}
+	r.Discard(card)
+	player.AddHand(r.PickCard(1))
+
+	result.IsReshuffled = r.reshuffles != reshuffles
+
+	return r.endTurn(player, playerIndex, false, result), nil
+}
+
+// endTurn kills the player when their hand is empty and hands the turn over,
+// skipping the next player when asked to
+func (r *Room) endTurn(player *Player, playerIndex int, isSkip bool, result TurnResult) TurnResult {
+	if len(player.Hand) == 0 {
+		r.kill(player)
+		result.IsDead = true
+	}
+
+	if winner := r.GetWinner(); winner != nil {
+		r.EndGame(winner.PlayerID)
+		result.Winner = winner
+		return result
+	}
+
+	if isSkip {
+		skippedIndex := r.GetPlayerIndex(r.NextPlayer(playerIndex))
+		r.NextPlayer(skippedIndex)
+	} else if r.TurnID == player.PlayerID {
+		r.NextPlayer(playerIndex)
+	}
+	result.NextPlayerID = r.TurnID
+
+	return result
+}
+
+// Eliminate kills a player outright, e.g. for running out of time
+func (r *Room) Eliminate(playerID string) (result TurnResult) {
+	player := r.PlayerMap[playerID]
+	if player == nil || !player.IsAlive {
+		return
+	}
+
+	playerIndex := r.GetPlayerIndex(playerID)
+	r.kill(player)
+	result.IsDead = true
+
+	if winner := r.GetWinner(); winner != nil {
+		r.EndGame(winner.PlayerID)
+		result.Winner = winner
+		return
+	}
+
+	if r.TurnID == playerID {
+		r.NextPlayer(playerIndex)
+	}
+	result.NextPlayerID = r.TurnID
+
+	return
+}
+
+// Leave takes a player out of the room, handing the host and the turn over
+// to someone else when needed
+func (r *Room) Leave(playerID string) (result LeaveResult) {
+	playerIndex := r.GetPlayerIndex(playerID)
+	if playerIndex < 0 {
+		return
+	}
+
+	if r.HostID == playerID {
+		result.NewHostID = r.NextHost()
+	}
+
+	if r.IsStarted && r.TurnID == playerID {
+		result.NextPlayerID = r.NextPlayer(playerIndex)
+	}
+
+	// whoever walks out of a game finishes it right there
+	if player := r.Players[playerIndex]; r.IsStarted {
+		if player.IsAlive {
+			r.kill(player)
+		}
+		r.Leavers[playerID] = player
+	}
+
+	r.RemovePlayer(playerIndex)
+	result.IsRemoved = true
+
+	if r.IsStarted {
+		if winner := r.GetWinner(); winner != nil {
+			r.EndGame(winner.PlayerID)
+			result.Winner = winner
+			result.NextPlayerID = ""
+		}
+	}
+
+	return
+}
+
+// Finisher returns a player of the last game, be they still seated or not
+func (r *Room) Finisher(playerID string) *Player {
+	if player, ok := r.PlayerMap[playerID]; ok {
+		return player
+	}
+
+	return r.Leavers[playerID]
+}
+
 func (r *Room) IsUsernameExist(name string) bool {
 	for _, player := range r.Players {
 		if player.Name == name {
 			return true
 		}
 	}
+	for _, spectator := range r.Spectators {
+		if spectator.Name == name {
+			return true
+		}
+	}
 	return false
 }
 
+// AddSpectator lets someone watch the room without taking a seat
+func (r *Room) AddSpectator(spectator *Player) {
+	spectator.IsAlive = false
+	r.Spectators = append(r.Spectators, spectator)
+}
+
+func (r *Room) GetSpectator(playerID string) *Player {
+	for _, spectator := range r.Spectators {
+		if spectator.PlayerID == playerID {
+			return spectator
+		}
+	}
+
+	return nil
+}
+
+func (r *Room) RemoveSpectator(playerID string) *Player {
+	for i, spectator := range r.Spectators {
+		if spectator.PlayerID == playerID {
+			r.Spectators = append(r.Spectators[:i], r.Spectators[i+1:]...)
+			return spectator
+		}
+	}
+
+	return nil
+}
+
+// PromoteSpectator gives a spectator a seat, it is only possible between
+// games and while there is a seat left
+func (r *Room) PromoteSpectator(playerID string) error {
+	if r.IsStarted {
+		return ErrGameStarted
+	}
+
+	if r.GetSpectator(playerID) == nil {
+		return ErrNotSpectator
+	}
+
+	if r.IsFull() {
+		return ErrRoomFull
+	}
+
+	r.AddPlayer(r.RemoveSpectator(playerID))
+
+	return nil
+}
+
 func (r *Room) AddPlayer(player *Player) {
 	r.PlayerMap[player.PlayerID] = player
 	r.Players = append(r.Players, player)
@@ -237,3 +651,38 @@ func (r *Room) GetWinner() (winner *Player) {
 
 	return nil
 }
+
+// deckCount is how many decks the room plays with, unless set in the
+// settings there are enough decks for half a deck to be left after dealing
+func (r *Room) deckCount() int {
+	if r.Settings.Decks > 0 {
+		return r.Settings.Decks
+	}
+
+	dealt := len(r.Players) * r.handSize()
+	return (dealt + cardsPerDeck/2 + cardsPerDeck - 1) / cardsPerDeck
+}
+
+func (r *Room) handSize() int {
+	if r.Rules.HandSize <= 0 {
+		return defaultHandSize
+	}
+
+	return r.Rules.HandSize
+}
+
+func (r *Room) kill(player *Player) {
+	player.IsAlive = false
+	r.Eliminations = append(r.Eliminations, player.PlayerID)
+}
+
+// HasHumans tells whether anyone but bots is seated in the room
+func (r *Room) HasHumans() bool {
+	for _, p := range r.Players {
+		if !p.IsBot {
+			return true
+		}
+	}
+
+	return false
+}
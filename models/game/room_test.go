@@ -97,3 +97,172 @@ func TestEndGame(t *testing.T) {
 	equals(t, emptyHand, player1.Hand)
 	equals(t, emptyHand, player2.Hand)
 }
+
+// playSeededGame plays a whole game where everyone plays their first card,
+// it returns the winner and the count after every turn
+func playSeededGame(seed int64) (string, []int) {
+	room := NewRoom("1", "fatt", 3)
+	room.AddPlayer(NewPlayer("player1", ""))
+	room.AddPlayer(NewPlayer("player2", ""))
+	room.AddPlayer(NewPlayer("player3", ""))
+	room.StartGameWithSeed(seed)
+
+	counts := []int{}
+	for room.GetWinner() == nil {
+		playerID := room.TurnID
+		player := room.PlayerMap[playerID]
+		playerIndex := room.GetPlayerIndex(playerID)
+
+		targetID := ""
+		for i := 1; i <= len(room.Players); i++ {
+			if p := room.Players[(playerIndex+i)%len(room.Players)]; p.IsAlive {
+				targetID = p.PlayerID
+				break
+			}
+		}
+
+		room.PlayCard(playerID, 0, true, targetID)
+		if len(player.Hand) == 0 {
+			player.IsAlive = false
+		}
+
+		counts = append(counts, room.Count)
+		if room.GetWinner() == nil && room.TurnID == playerID {
+			room.NextPlayer(playerIndex)
+		}
+	}
+
+	return room.GetWinner().Name, counts
+}
+
+func TestStartGameWithSeed(t *testing.T) {
+	room1 := NewRoom("1", "fatt", 2)
+	room1.AddPlayer(NewPlayer("player1", ""))
+	room1.AddPlayer(NewPlayer("player2", ""))
+
+	room2 := NewRoom("2", "fatt", 2)
+	room2.AddPlayer(NewPlayer("player1", ""))
+	room2.AddPlayer(NewPlayer("player2", ""))
+
+	turnID1 := room1.StartGameWithSeed(42)
+	turnID2 := room2.StartGameWithSeed(42)
+
+	equals(t, int64(42), room1.Seed)
+	equals(t, room1.Deck, room2.Deck)
+	equals(t, room1.Players[0].Hand, room2.Players[0].Hand)
+	equals(t, room1.Players[1].Hand, room2.Players[1].Hand)
+	equals(t, room1.GetPlayerIndex(turnID1), room2.GetPlayerIndex(turnID2))
+}
+
+func TestReplaySeededGame(t *testing.T) {
+	winner1, counts1 := playSeededGame(1337)
+	winner2, counts2 := playSeededGame(1337)
+
+	equals(t, winner1, winner2)
+	equals(t, counts1, counts2)
+}
+
+func TestRestoreRandom(t *testing.T) {
+	room := NewRoom("1", "fatt", 2)
+	room.SetSeed(7)
+	room.Deck = []Card{}
+	room.Discard(Card{Rank: 1}, Card{Rank: 2})
+	room.PickCard(1)
+
+	restored := NewRoom("1", "fatt", 2)
+	restored.RestoreRandom(room.Seed, room.Draws())
+
+	equals(t, room.rng.Int63(), restored.rng.Int63())
+}
+
+func TestPromoteSpectator(t *testing.T) {
+	player1 := NewPlayer("player1", "")
+	player2 := NewPlayer("player2", "")
+	spectator := NewPlayer("spectator", "")
+	room := NewRoom("1", player1.PlayerID, 2)
+	room.AddPlayer(player1)
+	room.AddSpectator(spectator)
+
+	equals(t, true, room.IsUsernameExist("spectator"))
+	equals(t, ErrNotSpectator, room.PromoteSpectator(player1.PlayerID))
+
+	room.AddPlayer(player2)
+	room.StartGame()
+	equals(t, 2, len(room.Players))
+	equals(t, ErrGameStarted, room.PromoteSpectator(spectator.PlayerID))
+
+	room.EndGame(player1.PlayerID)
+	equals(t, ErrRoomFull, room.PromoteSpectator(spectator.PlayerID))
+
+	room.Leave(player2.PlayerID)
+	equals(t, nil, room.PromoteSpectator(spectator.PlayerID))
+	equals(t, spectator, room.PlayerMap[spectator.PlayerID])
+	equals(t, 0, len(room.Spectators))
+}
+
+func TestReshuffleDiscardPile(t *testing.T) {
+	player1 := NewPlayer("player1", "")
+	player2 := NewPlayer("player2", "")
+	room := NewRoom("1", player1.PlayerID, 2)
+	room.AddPlayer(player1)
+	room.AddPlayer(player2)
+	room.StartGameWithSeed(3)
+	equals(t, 48, len(room.Deck))
+
+	room.TurnID = player1.PlayerID
+	room.Count = 0
+	player1.Hand = []Card{{Rank: 2}, {Rank: 3}}
+	room.Deck = []Card{}
+	room.Discard(Card{Rank: 5})
+
+	result, err := room.Play(player1.PlayerID, Move{HandIndex: 0})
+	equals(t, nil, err)
+	equals(t, true, result.IsReshuffled)
+	equals(t, 2, len(player1.Hand))
+	equals(t, 1, len(room.Deck))
+	equals(t, 0, len(room.DiscardPile))
+
+	room.Deck = []Card{}
+	equals(t, 0, len(room.PickCard(1)))
+}
+
+func TestForceDiscard(t *testing.T) {
+	player1 := NewPlayer("player1", "")
+	player2 := NewPlayer("player2", "")
+	room := NewRoom("1", player1.PlayerID, 2)
+	room.AddPlayer(player1)
+	room.AddPlayer(player2)
+	room.StartGameWithSeed(3)
+
+	room.TurnID = player1.PlayerID
+	room.Count = 10
+	player1.Hand = []Card{{Rank: 2}, {Rank: 3}}
+	room.DiscardPile = []Card{}
+
+	result, err := room.ForceDiscard(player1.PlayerID)
+	equals(t, nil, err)
+	equals(t, false, result.IsPlayed)
+	equals(t, 10, room.Count)
+	equals(t, []Card{{Rank: 2}}, room.DiscardPile)
+	equals(t, 2, len(player1.Hand))
+	equals(t, Card{Rank: 3}, player1.Hand[0])
+	equals(t, player2.PlayerID, result.NextPlayerID)
+
+	_, err = room.ForceDiscard(player1.PlayerID)
+	equals(t, ErrNotYourTurn, err)
+}
+
+func TestDeckCount(t *testing.T) {
+	room := NewRoom("1", "fatt", 10)
+	for i := 0; i < 10; i++ {
+		room.AddPlayer(NewPlayer(fmt.Sprintf("player%v", i), ""))
+	}
+	room.UpdateSettings(Settings{Capacity: 10, HandSize: 4})
+	room.StartGameWithSeed(1)
+	equals(t, 2*52-40, len(room.Deck))
+
+	room.EndGame(room.Players[0].PlayerID)
+	room.UpdateSettings(Settings{Capacity: 10, HandSize: 4, Decks: 3})
+	room.StartGameWithSeed(1)
+	equals(t, 3*52-40, len(room.Deck))
+}
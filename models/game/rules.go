@@ -0,0 +1,102 @@
+package game
+
+import "fmt"
+
+const (
+	// AddEffect adds the rank of the card to the count
+	AddEffect = "add"
+	// ChoiceEffect lets the player either add or subtract the value
+	ChoiceEffect = "choice"
+	// ReverseEffect flips the direction of play
+	ReverseEffect = "reverse"
+	// TargetEffect hands the turn over to a player of choice
+	TargetEffect = "target"
+	// SkipEffect skips the next player
+	SkipEffect = "skip"
+	// LimitEffect sets the count right to the limit
+	LimitEffect = "limit"
+
+	ClassicRules = "classic"
+
+	Limit99Variant   = "limit-99"
+	Queen10Variant   = "queen-10"
+	EightSkipVariant = "eight-skip"
+)
+
+// RankEffect is what playing a card of a given rank does
+type RankEffect struct {
+	Effect string `json:"effect"`
+	Value  int    `json:"value,omitempty"`
+}
+
+// RuleSet describes how a game is played, nobody may push the count past
+// the limit
+type RuleSet struct {
+	Name     string             `json:"name"`
+	Variants []string           `json:"variants,omitempty"`
+	Limit    int                `json:"limit"`
+	HandSize int                `json:"hand_size"`
+	Effects  map[int]RankEffect `json:"effects"`
+}
+
+func NewClassicRuleSet() RuleSet {
+	return RuleSet{
+		Name:     ClassicRules,
+		Limit:    100,
+		HandSize: defaultHandSize,
+		Effects: map[int]RankEffect{
+			1:  {Effect: ChoiceEffect, Value: 1},
+			2:  {Effect: AddEffect},
+			3:  {Effect: AddEffect},
+			4:  {Effect: ReverseEffect},
+			5:  {Effect: AddEffect},
+			6:  {Effect: AddEffect},
+			7:  {Effect: TargetEffect},
+			8:  {Effect: AddEffect},
+			9:  {Effect: AddEffect},
+			10: {Effect: AddEffect},
+			11: {Effect: ChoiceEffect, Value: 10},
+			12: {Effect: ChoiceEffect, Value: 20},
+			13: {Effect: LimitEffect},
+		},
+	}
+}
+
+// NewRuleSet builds the rules picked in the settings, that is the classic
+// rules with the chosen variants on top
+func NewRuleSet(settings Settings) (RuleSet, error) {
+	result := NewClassicRuleSet()
+	if settings.HandSize > 0 {
+		result.HandSize = settings.HandSize
+	}
+
+	for _, variant := range settings.Variants {
+		switch variant {
+		case Limit99Variant:
+			result.Limit = 99
+		case Queen10Variant:
+			result.Effects[12] = RankEffect{Effect: ChoiceEffect, Value: 10}
+		case EightSkipVariant:
+			result.Effects[8] = RankEffect{Effect: SkipEffect}
+		default:
+			return result, fmt.Errorf("unknown rule variant %v", variant)
+		}
+		result.Variants = append(result.Variants, variant)
+	}
+
+	return result, nil
+}
+
+// Effect tells what playing a card of the rank does
+func (rs RuleSet) Effect(rank int) RankEffect {
+	if effect, ok := rs.Effects[rank]; ok {
+		return effect
+	}
+
+	return RankEffect{Effect: AddEffect}
+}
+
+// IsSpecial tells whether a card does anything but adding its rank
+func (rs RuleSet) IsSpecial(card Card) bool {
+	return rs.Effect(card.Rank).Effect != AddEffect
+}
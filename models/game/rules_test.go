@@ -0,0 +1,64 @@
+package game
+
+import "testing"
+
+func newRulesRoom(t *testing.T, variants ...string) (*Room, []*Player) {
+	players := []*Player{NewPlayer("player1", ""), NewPlayer("player2", ""), NewPlayer("player3", "")}
+	room := NewRoom("1", players[0].PlayerID, 3)
+	for _, p := range players {
+		room.AddPlayer(p)
+	}
+
+	err := room.UpdateSettings(Settings{Capacity: 3, Variants: variants})
+	equals(t, nil, err)
+
+	room.StartGameWithSeed(1)
+	room.TurnID = players[0].PlayerID
+	room.IsClockwise = true
+
+	return room, players
+}
+
+func TestClassicRules(t *testing.T) {
+	room, players := newRulesRoom(t)
+	equals(t, ClassicRules, room.Rules.Name)
+
+	room.Count = 50
+	players[0].Hand = []Card{{Rank: 12}, {Rank: 5}}
+	_, err := room.Play(players[0].PlayerID, Move{HandIndex: 0, IsAdd: false})
+	equals(t, nil, err)
+	equals(t, 30, room.Count)
+	equals(t, players[1].PlayerID, room.TurnID)
+
+	players[1].Hand = []Card{{Rank: 13}, {Rank: 5}}
+	_, err = room.Play(players[1].PlayerID, Move{HandIndex: 0})
+	equals(t, nil, err)
+	equals(t, 100, room.Count)
+}
+
+func TestRuleVariants(t *testing.T) {
+	room, players := newRulesRoom(t, Limit99Variant, Queen10Variant, EightSkipVariant)
+	equals(t, 99, room.Rules.Limit)
+	equals(t, RankEffect{Effect: ChoiceEffect, Value: 10}, room.Rules.Effect(12))
+	equals(t, true, room.Rules.IsSpecial(Card{Rank: 8}))
+
+	room.Count = 50
+	players[0].Hand = []Card{{Rank: 12}, {Rank: 5}}
+	_, err := room.Play(players[0].PlayerID, Move{HandIndex: 0, IsAdd: true})
+	equals(t, nil, err)
+	equals(t, 60, room.Count)
+
+	players[1].Hand = []Card{{Rank: 8}, {Rank: 5}}
+	result, err := room.Play(players[1].PlayerID, Move{HandIndex: 0})
+	equals(t, nil, err)
+	equals(t, 60, room.Count)
+	equals(t, players[0].PlayerID, result.NextPlayerID)
+
+	players[0].Hand = []Card{{Rank: 13}, {Rank: 5}}
+	_, err = room.Play(players[0].PlayerID, Move{HandIndex: 0})
+	equals(t, nil, err)
+	equals(t, 99, room.Count)
+
+	settings := Settings{Variants: []string{"joker-wild"}}
+	assert(t, settings.Validate() != nil, "Unknown variant should be rejected")
+}
@@ -0,0 +1,133 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// DiscardPenalty throws away the first card of an idle player without
+	// playing it
+	DiscardPenalty = "discard"
+	// EliminatePenalty kills an idle player
+	EliminatePenalty = "eliminate"
+
+	defaultCapacity = 4
+	minCapacity     = 2
+	maxCapacity     = 10
+	defaultHandSize = 2
+	maxHandSize     = 4
+	maxDecks        = 4
+	maxTargetScore  = 100
+	maxRounds       = 20
+	maxTurnTimeout  = 300
+	maxPassword     = 64
+
+	defaultVoteKickWindow = 30
+	minVoteKickWindow     = 10
+	maxVoteKickWindow     = 300
+)
+
+// Settings :nodoc:
+type Settings struct {
+	Capacity int `json:"capacity"`
+	HandSize int `json:"hand_size"`
+	// Decks is how many decks are shuffled together, 0 scales the number of
+	// decks with the table
+	Decks int `json:"decks"`
+	// TurnTimeout is how many seconds a player has to make a move, 0 means
+	// no time limit
+	TurnTimeout int    `json:"turn_timeout"`
+	TurnPenalty string `json:"turn_penalty,omitempty"`
+	// IsPrivate keeps the room out of public listings, only those who know
+	// the room ID can join
+	IsPrivate bool `json:"is_private"`
+	// Password is only ever sent by clients, the room keeps it aside and
+	// tells everyone whether there is one through HasPassword
+	Password    string `json:"password,omitempty"`
+	HasPassword bool   `json:"has_password"`
+	// Variants tweak the classic rules, see NewRuleSet
+	Variants []string `json:"variants,omitempty"`
+	// a match is played when either a target score or a number of rounds
+	// is set, see Match
+	TargetScore int `json:"target_score"`
+	Rounds      int `json:"rounds"`
+	// VoteKickWindow is how many seconds a vote on kicking someone stays
+	// open
+	VoteKickWindow int `json:"vote_kick_window"`
+}
+
+func NewSettings() Settings {
+	return Settings{
+		Capacity:    defaultCapacity,
+		HandSize:    defaultHandSize,
+		TurnTimeout: 0,
+		TurnPenalty: DiscardPenalty,
+
+		VoteKickWindow: defaultVoteKickWindow,
+	}
+}
+
+// Validate checks the settings requested by a client and fills in the
+// defaults of whatever is left empty
+func (s *Settings) Validate() error {
+	if s.Capacity == 0 {
+		s.Capacity = defaultCapacity
+	}
+	if s.Capacity < minCapacity || s.Capacity > maxCapacity {
+		return fmt.Errorf("capacity should be between %v and %v players", minCapacity, maxCapacity)
+	}
+
+	if s.HandSize == 0 {
+		s.HandSize = defaultHandSize
+	}
+	if s.HandSize < 1 || s.HandSize > maxHandSize {
+		return fmt.Errorf("hand size should be between 1 and %v cards", maxHandSize)
+	}
+
+	if s.Decks < 0 || s.Decks > maxDecks {
+		return fmt.Errorf("decks should be between 1 and %v, or 0 to pick automatically", maxDecks)
+	}
+
+	if s.TurnTimeout < 0 || s.TurnTimeout > maxTurnTimeout {
+		return errors.New("turn timeout should be between 0 and 300 seconds")
+	}
+
+	switch s.TurnPenalty {
+	case "":
+		s.TurnPenalty = DiscardPenalty
+	case DiscardPenalty, EliminatePenalty:
+	default:
+		return errors.New("turn penalty should be either discard or eliminate")
+	}
+
+	if len(s.Password) > maxPassword {
+		return fmt.Errorf("password should not be longer than %v characters", maxPassword)
+	}
+
+	if _, err := NewRuleSet(*s); err != nil {
+		return err
+	}
+
+	if s.TargetScore < 0 || s.TargetScore > maxTargetScore {
+		return fmt.Errorf("target score should be between 0 and %v", maxTargetScore)
+	}
+
+	if s.Rounds < 0 || s.Rounds > maxRounds {
+		return fmt.Errorf("rounds should be between 0 and %v", maxRounds)
+	}
+
+	if s.VoteKickWindow == 0 {
+		s.VoteKickWindow = defaultVoteKickWindow
+	}
+	if s.VoteKickWindow < minVoteKickWindow || s.VoteKickWindow > maxVoteKickWindow {
+		return fmt.Errorf("vote kick window should be between %v and %v seconds", minVoteKickWindow, maxVoteKickWindow)
+	}
+
+	return nil
+}
+
+// IsMatch tells whether games are played as rounds of a match
+func (s Settings) IsMatch() bool {
+	return s.TargetScore > 0 || s.Rounds > 0
+}
@@ -0,0 +1,51 @@
+package game
+
+import "testing"
+
+func TestValidateSettings(t *testing.T) {
+	settings := Settings{TurnTimeout: 30}
+	assert(t, settings.Validate() == nil, "Settings should be valid")
+	equals(t, DiscardPenalty, settings.TurnPenalty)
+	equals(t, defaultCapacity, settings.Capacity)
+	equals(t, defaultHandSize, settings.HandSize)
+
+	settings = Settings{TurnTimeout: -1}
+	assert(t, settings.Validate() != nil, "Negative turn timeout should be rejected")
+
+	settings = Settings{TurnTimeout: 10, TurnPenalty: "explode"}
+	assert(t, settings.Validate() != nil, "Unknown turn penalty should be rejected")
+
+	settings = Settings{Capacity: 1}
+	assert(t, settings.Validate() != nil, "Capacity below 2 should be rejected")
+
+	settings = Settings{Capacity: maxCapacity + 1}
+	assert(t, settings.Validate() != nil, "Capacity above the limit should be rejected")
+
+	settings = Settings{HandSize: maxHandSize + 1}
+	assert(t, settings.Validate() != nil, "Hand size above the limit should be rejected")
+}
+
+func TestUpdateSettings(t *testing.T) {
+	player1 := NewPlayer("player1", "")
+	player2 := NewPlayer("player2", "")
+	player3 := NewPlayer("player3", "")
+	room := NewRoom("1", player1.PlayerID, 4)
+	room.AddPlayer(player1)
+	room.AddPlayer(player2)
+	room.AddPlayer(player3)
+
+	err := room.UpdateSettings(Settings{Capacity: 2})
+	equals(t, ErrTooManyPlayers, err)
+
+	err = room.UpdateSettings(Settings{Capacity: 3, HandSize: 3, Password: "secret"})
+	equals(t, nil, err)
+	equals(t, 3, room.Capacity)
+	equals(t, "", room.Settings.Password)
+	equals(t, true, room.Settings.HasPassword)
+	equals(t, true, room.CheckPassword("secret"))
+	equals(t, false, room.CheckPassword("guess"))
+
+	room.StartGame()
+	equals(t, 3, len(player1.Hand))
+	equals(t, ErrGameStarted, room.UpdateSettings(Settings{}))
+}
@@ -0,0 +1,52 @@
+package game
+
+// RoomState is everything a member needs to draw the room from scratch, Seq
+// is the number of the last broadcast the state includes
+type RoomState struct {
+	Seq         uint64    `json:"seq"`
+	RoomID      string    `json:"id_room"`
+	HostID      string    `json:"id_host"`
+	TurnID      string    `json:"id_turn"`
+	IsStarted   bool      `json:"is_started"`
+	IsClockwise bool      `json:"is_clockwise"`
+	IsLocked    bool      `json:"is_locked"`
+	Count       int       `json:"count"`
+	DeckSize    int       `json:"deck_size"`
+	LastCard    *Card     `json:"last_card,omitempty"`
+	Players     []*Player `json:"players"`
+	Spectators  []*Player `json:"spectators"`
+	Settings    Settings  `json:"settings"`
+	Match       *Match    `json:"match,omitempty"`
+	// Hand is the hand of the member asking, it is empty for spectators
+	Hand []Card `json:"hand"`
+}
+
+func NewRoomState(room *Room, playerID string) RoomState {
+	result := RoomState{
+		Seq:         room.Seq,
+		RoomID:      room.RoomID,
+		HostID:      room.HostID,
+		TurnID:      room.TurnID,
+		IsStarted:   room.IsStarted,
+		IsClockwise: room.IsClockwise,
+		IsLocked:    room.IsLocked,
+		Count:       room.Count,
+		DeckSize:    len(room.Deck),
+		Players:     append([]*Player{}, room.Players...),
+		Spectators:  append([]*Player{}, room.Spectators...),
+		Settings:    room.Settings,
+		Match:       room.Match,
+		Hand:        []Card{},
+	}
+
+	if len(room.DiscardPile) > 0 {
+		lastCard := room.DiscardPile[len(room.DiscardPile)-1]
+		result.LastCard = &lastCard
+	}
+
+	if player := room.PlayerMap[playerID]; player != nil {
+		result.Hand = append(result.Hand, player.Hand...)
+	}
+
+	return result
+}
@@ -0,0 +1,32 @@
+package game
+
+import "testing"
+
+func TestNewRoomState(t *testing.T) {
+	players := []*Player{NewPlayer("player1", ""), NewPlayer("player2", "")}
+	room := NewRoom("1", players[0].PlayerID, 4)
+	for _, p := range players {
+		room.AddPlayer(p)
+	}
+	spectator := NewPlayer("spectator", "")
+	room.AddSpectator(spectator)
+
+	room.StartGameWithSeed(1)
+	room.Seq = 5
+
+	state := NewRoomState(room, players[1].PlayerID)
+	equals(t, uint64(5), state.Seq)
+	equals(t, room.TurnID, state.TurnID)
+	equals(t, players[0].PlayerID, state.HostID)
+	equals(t, len(room.Deck), state.DeckSize)
+	equals(t, players[1].Hand, state.Hand)
+	equals(t, []*Player{spectator}, state.Spectators)
+	equals(t, true, state.Players[0].IsAlive)
+	equals(t, (*Card)(nil), state.LastCard)
+
+	// the snapshot should not change along with the room
+	state.Hand[0] = Card{}
+	equals(t, false, players[1].Hand[0] == Card{})
+
+	equals(t, []Card{}, NewRoomState(room, spectator.PlayerID).Hand)
+}
@@ -0,0 +1,183 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	RandomStrategy    = "random"
+	GreedyStrategy    = "greedy"
+	LookaheadStrategy = "lookahead"
+)
+
+// Strategy decides the moves of a bot, it only gets to see what a human
+// sitting in the same seat would see
+type Strategy interface {
+	Name() string
+	ChooseMove(room *Room, playerID string) Move
+}
+
+// NewStrategy builds the strategy of the given name, an empty name picks the
+// random strategy
+func NewStrategy(name string) (Strategy, error) {
+	switch name {
+	case "", RandomStrategy:
+		// bots get their own source, drawing from the one of the room would
+		// change the shuffles of the game
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		return &randomStrategy{rng: rng}, nil
+	case GreedyStrategy:
+		return greedyStrategy{}, nil
+	case LookaheadStrategy:
+		return lookaheadStrategy{}, nil
+	default:
+		return nil, fmt.Errorf("unknown strategy %v", name)
+	}
+}
+
+// candidate is a move along with what it would leave the room with
+type candidate struct {
+	move        Move
+	card        Card
+	count       int
+	isClockwise bool
+}
+
+// candidates lists every legal move of the player along with what it would
+// leave the room with
+func (r *Room) candidates(playerID string) []candidate {
+	result := []candidate{}
+
+	player := r.PlayerMap[playerID]
+	if player == nil {
+		return result
+	}
+	hand := player.Hand
+
+	for _, move := range r.LegalMoves(playerID) {
+		c := candidate{
+			move:        move,
+			card:        hand[move.HandIndex],
+			count:       r.Count,
+			isClockwise: r.IsClockwise,
+		}
+		if r.Rules.Effect(c.card.Rank).Effect != TargetEffect {
+			c.count, c.isClockwise, _ = r.cardEffect(c.card, move.IsAdd)
+		}
+
+		result = append(result, c)
+	}
+
+	return result
+}
+
+// discard is what a bot does when none of its cards can be played
+func discard() Move {
+	return Move{HandIndex: 0, IsDiscard: true}
+}
+
+// randomStrategy plays any card that can be played
+type randomStrategy struct {
+	rng *rand.Rand
+}
+
+func (s *randomStrategy) Name() string {
+	return RandomStrategy
+}
+
+func (s *randomStrategy) ChooseMove(room *Room, playerID string) Move {
+	candidates := room.candidates(playerID)
+	if len(candidates) == 0 {
+		return discard()
+	}
+
+	return candidates[s.rng.Intn(len(candidates))].move
+}
+
+// greedyStrategy keeps the count as low as it can
+type greedyStrategy struct{}
+
+func (greedyStrategy) Name() string {
+	return GreedyStrategy
+}
+
+func (greedyStrategy) ChooseMove(room *Room, playerID string) Move {
+	candidates := room.candidates(playerID)
+	if len(candidates) == 0 {
+		return discard()
+	}
+
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.count < best.count {
+			best = c
+		}
+	}
+
+	return best.move
+}
+
+// lookaheadStrategy also considers whether the card kept in hand can still
+// be played on the next turn, and leaves the count as high as it safely can
+// for the next player
+type lookaheadStrategy struct{}
+
+func (lookaheadStrategy) Name() string {
+	return LookaheadStrategy
+}
+
+func (lookaheadStrategy) ChooseMove(room *Room, playerID string) Move {
+	candidates := room.candidates(playerID)
+	if len(candidates) == 0 {
+		return discard()
+	}
+
+	hand := room.PlayerMap[playerID].Hand
+
+	best := candidates[0]
+	bestScore := lookaheadScore(room, hand, best)
+	for _, c := range candidates[1:] {
+		if score := lookaheadScore(room, hand, c); score > bestScore {
+			best = c
+			bestScore = score
+		}
+	}
+
+	return best.move
+}
+
+// lookaheadScore rates a move, a move that leaves the bot with nothing to
+// play next turn is the worst there is
+func lookaheadScore(room *Room, hand []Card, c candidate) int {
+	after := &Room{Count: c.count, IsClockwise: c.isClockwise, Rules: room.Rules}
+
+	keepsPlayable := false
+	for i, card := range hand {
+		if i == c.move.HandIndex {
+			continue
+		}
+
+		if room.Rules.IsSpecial(card) {
+			keepsPlayable = true
+			break
+		}
+		if _, _, err := after.cardEffect(card, true); err == nil {
+			keepsPlayable = true
+			break
+		}
+	}
+
+	score := c.count
+	if !keepsPlayable {
+		score -= 200
+	}
+
+	// saving the strongest cards for when the count runs high
+	if room.Rules.IsSpecial(c.card) && room.Count < room.Rules.Limit*4/5 {
+		score -= 50
+	}
+
+	return score
+}
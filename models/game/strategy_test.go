@@ -0,0 +1,44 @@
+package game
+
+import "testing"
+
+func TestStrategiesFinishGame(t *testing.T) {
+	for _, name := range []string{RandomStrategy, GreedyStrategy, LookaheadStrategy} {
+		strategy, err := NewStrategy(name)
+		equals(t, nil, err)
+		equals(t, name, strategy.Name())
+
+		room := NewRoom("1", "fatt", 3)
+		room.AddPlayer(NewBot("bot1", name))
+		room.AddPlayer(NewBot("bot2", name))
+		room.AddPlayer(NewBot("bot3", name))
+		room.StartGameWithSeed(99)
+
+		for turn := 0; room.IsStarted; turn++ {
+			assert(t, turn < 1000, "Game of %v bots should end", name)
+
+			move := strategy.ChooseMove(room, room.TurnID)
+			_, err := room.Play(room.TurnID, move)
+			assert(t, err == nil, "Move %v of %v strategy should be accepted: %v", move, name, err)
+		}
+	}
+
+	_, err := NewStrategy("cheat")
+	assert(t, err != nil, "Unknown strategy should be rejected")
+}
+
+func TestGreedyStrategy(t *testing.T) {
+	room := NewRoom("1", "fatt", 2)
+	bot := NewBot("bot", GreedyStrategy)
+	room.AddPlayer(bot)
+	room.AddPlayer(NewPlayer("player", ""))
+	room.StartGameWithSeed(1)
+
+	room.Count = 50
+	bot.Hand = []Card{{Rank: 9}, {Rank: 12}}
+	equals(t, Move{HandIndex: 1, IsAdd: false}, greedyStrategy{}.ChooseMove(room, bot.PlayerID))
+
+	room.Count = 95
+	bot.Hand = []Card{{Rank: 9}, {Rank: 8}}
+	equals(t, discard(), greedyStrategy{}.ChooseMove(room, bot.PlayerID))
+}
@@ -0,0 +1,29 @@
+package game
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidTicket = errors.New("Invalid ticket")
+	ErrWrongRoom     = errors.New("Ticket was issued for another room")
+)
+
+// Identity is who a connection has been let in as. It is read from the
+// signed ticket the connection was opened with, never from client messages
+type Identity struct {
+	PlayerID  string `json:"id_player"`
+	AccountID string `json:"id_account,omitempty"`
+	// IP is where the connection comes from, it is not part of the ticket
+	IP string `json:"ip,omitempty"`
+}
+
+// Ticket lets its bearer open a connection to a room as the given identity
+// until it expires
+type Ticket struct {
+	Token     string    `json:"ticket"`
+	RoomID    string    `json:"id_room"`
+	PlayerID  string    `json:"id_player"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
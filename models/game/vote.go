@@ -0,0 +1,138 @@
+package game
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	VotePassed = "passed"
+	VoteFailed = "failed"
+	VoteClosed = "expired"
+)
+
+var (
+	ErrVoteInProgress = errors.New("A vote on this player is already running")
+	ErrNoVote         = errors.New("There is no vote on this player")
+	ErrAlreadyVoted   = errors.New("You have already voted")
+	ErrVoteOnSelf     = errors.New("You can not vote on yourself")
+)
+
+// VoteKick is a running vote on kicking a player. Votes maps each voter to
+// whether they want the target out
+type VoteKick struct {
+	TargetID  string          `json:"id_target"`
+	IssuerID  string          `json:"id_issuer"`
+	Votes     map[string]bool `json:"votes"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// VoteTally is where a vote stands. Only the humans seated right now, but
+// the target, have a say
+type VoteTally struct {
+	TargetID   string    `json:"id_target"`
+	Yes        int       `json:"yes"`
+	No         int       `json:"no"`
+	Electorate int       `json:"electorate"`
+	Needed     int       `json:"needed"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// StartVoteKick opens a vote on kicking the target, the issuer votes yes
+// right away
+func (r *Room) StartVoteKick(issuerID, targetID string) (*VoteKick, error) {
+	if r.PlayerMap[targetID] == nil {
+		return nil, ErrPlayerNotFound
+	}
+
+	if issuerID == targetID {
+		return nil, ErrVoteOnSelf
+	}
+
+	if _, ok := r.VoteBallot[targetID]; ok {
+		return nil, ErrVoteInProgress
+	}
+
+	window := r.Settings.VoteKickWindow
+	if window <= 0 {
+		window = defaultVoteKickWindow
+	}
+
+	result := &VoteKick{
+		TargetID:  targetID,
+		IssuerID:  issuerID,
+		Votes:     map[string]bool{issuerID: true},
+		ExpiresAt: time.Now().UTC().Add(time.Duration(window) * time.Second),
+	}
+	r.VoteBallot[targetID] = result
+
+	return result, nil
+}
+
+// CastVote records the vote of a player, everyone gets a single vote
+func (r *Room) CastVote(voterID, targetID string, isYes bool) error {
+	vote, ok := r.VoteBallot[targetID]
+	if !ok {
+		return ErrNoVote
+	}
+
+	if voterID == targetID {
+		return ErrVoteOnSelf
+	}
+
+	if _, ok := vote.Votes[voterID]; ok {
+		return ErrAlreadyVoted
+	}
+
+	vote.Votes[voterID] = isYes
+	return nil
+}
+
+// TallyVoteKick counts the votes on the target
+func (r *Room) TallyVoteKick(targetID string) VoteTally {
+	result := VoteTally{TargetID: targetID}
+
+	vote, ok := r.VoteBallot[targetID]
+	if !ok {
+		return result
+	}
+	result.ExpiresAt = vote.ExpiresAt
+
+	for _, p := range r.Players {
+		if p.IsBot || p.PlayerID == targetID {
+			continue
+		}
+
+		result.Electorate++
+		if isYes, ok := vote.Votes[p.PlayerID]; ok && isYes {
+			result.Yes++
+		} else if ok {
+			result.No++
+		}
+	}
+	result.Needed = result.Electorate/2 + 1
+
+	return result
+}
+
+// Outcome tells how the vote ended, or nothing while it could still go
+// either way. A closed vote fails unless it has passed
+func (t VoteTally) Outcome(isClosed bool) string {
+	undecided := t.Electorate - t.Yes - t.No
+
+	switch {
+	case t.Yes >= t.Needed:
+		return VotePassed
+	case t.Yes+undecided < t.Needed:
+		return VoteFailed
+	case isClosed:
+		return VoteClosed
+	default:
+		return ""
+	}
+}
+
+// CloseVoteKick forgets about the vote on the target
+func (r *Room) CloseVoteKick(targetID string) {
+	delete(r.VoteBallot, targetID)
+}
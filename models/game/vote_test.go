@@ -0,0 +1,53 @@
+package game
+
+import "testing"
+
+func newVoteRoom() (*Room, []*Player) {
+	players := []*Player{NewPlayer("player1", ""), NewPlayer("player2", ""), NewPlayer("player3", ""), NewPlayer("player4", "")}
+	room := NewRoom("1", players[0].PlayerID, 5)
+	for _, p := range players {
+		room.AddPlayer(p)
+	}
+	room.AddPlayer(NewBot("bot", RandomStrategy))
+
+	return room, players
+}
+
+func TestVoteKickPasses(t *testing.T) {
+	room, players := newVoteRoom()
+	target := players[3].PlayerID
+
+	_, err := room.StartVoteKick(players[0].PlayerID, target)
+	equals(t, nil, err)
+	_, err = room.StartVoteKick(players[1].PlayerID, target)
+	equals(t, ErrVoteInProgress, err)
+
+	tally := room.TallyVoteKick(target)
+	equals(t, 3, tally.Electorate)
+	equals(t, 2, tally.Needed)
+	equals(t, 1, tally.Yes)
+	equals(t, "", tally.Outcome(false))
+	equals(t, VoteClosed, tally.Outcome(true))
+
+	equals(t, ErrVoteOnSelf, room.CastVote(target, target, false))
+	equals(t, ErrAlreadyVoted, room.CastVote(players[0].PlayerID, target, true))
+	equals(t, nil, room.CastVote(players[1].PlayerID, target, true))
+	equals(t, VotePassed, room.TallyVoteKick(target).Outcome(false))
+}
+
+func TestVoteKickFails(t *testing.T) {
+	room, players := newVoteRoom()
+	target := players[3].PlayerID
+
+	room.StartVoteKick(players[0].PlayerID, target)
+	equals(t, nil, room.CastVote(players[1].PlayerID, target, false))
+	equals(t, "", room.TallyVoteKick(target).Outcome(false))
+
+	equals(t, nil, room.CastVote(players[2].PlayerID, target, false))
+	tally := room.TallyVoteKick(target)
+	equals(t, 2, tally.No)
+	equals(t, VoteFailed, tally.Outcome(false))
+
+	room.CloseVoteKick(target)
+	equals(t, ErrNoVote, room.CastVote(players[1].PlayerID, target, true))
+}
@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	accountModel "github.com/aryuuu/cepex-server/models/account"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const accountSchema = `
+CREATE TABLE IF NOT EXISTS accounts (
+	id_account    TEXT PRIMARY KEY,
+	username      TEXT NOT NULL UNIQUE COLLATE NOCASE,
+	password_hash TEXT NOT NULL,
+	rating        INTEGER NOT NULL,
+	games_played  INTEGER NOT NULL DEFAULT 0,
+	wins          INTEGER NOT NULL DEFAULT 0,
+	created_at    DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS accounts_rating ON accounts (rating DESC);
+`
+
+const accountColumns = "id_account, username, password_hash, rating, games_played, wins, created_at"
+
+type sqliteAccountRepo struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the database file at the given path, creating it when
+// needed
+func OpenSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_foreign_keys=on")
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite allows a single writer at a time anyway
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+// NewSQLiteAccountRepo keeps accounts in a sqlite database, the schema is
+// created when missing
+func NewSQLiteAccountRepo(db *sql.DB) (accountModel.AccountRepository, error) {
+	if _, err := db.Exec(accountSchema); err != nil {
+		return nil, fmt.Errorf("NewSQLiteAccountRepo: failed to create schema: %v", err)
+	}
+
+	result := &sqliteAccountRepo{
+		db: db,
+	}
+
+	return result, nil
+}
+
+func (r *sqliteAccountRepo) Create(account *accountModel.Account) error {
+	_, err := r.db.Exec(
+		"INSERT INTO accounts ("+accountColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		account.AccountID,
+		account.Username,
+		account.PasswordHash,
+		account.Rating,
+		account.GamesPlayed,
+		account.Wins,
+		account.CreatedAt.UTC(),
+	)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: accounts.username") {
+		return accountModel.ErrUsernameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("sqliteAccountRepo.Create: failed to insert account: %v", err)
+	}
+
+	return nil
+}
+
+func (r *sqliteAccountRepo) Get(accountID string) (*accountModel.Account, error) {
+	row := r.db.QueryRow("SELECT "+accountColumns+" FROM accounts WHERE id_account = ?", accountID)
+
+	result, err := scanAccount(row)
+	if err == sql.ErrNoRows {
+		return nil, accountModel.ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sqliteAccountRepo.Get: failed to get account: %v", err)
+	}
+
+	return result, nil
+}
+
+func (r *sqliteAccountRepo) GetByUsername(username string) (*accountModel.Account, error) {
+	row := r.db.QueryRow("SELECT "+accountColumns+" FROM accounts WHERE username = ?", username)
+
+	result, err := scanAccount(row)
+	if err == sql.ErrNoRows {
+		return nil, accountModel.ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sqliteAccountRepo.GetByUsername: failed to get account: %v", err)
+	}
+
+	return result, nil
+}
+
+func (r *sqliteAccountRepo) List(limit int) ([]accountModel.Account, error) {
+	rows, err := r.db.Query(
+		"SELECT "+accountColumns+" FROM accounts WHERE games_played > 0 ORDER BY rating DESC, username LIMIT ?",
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("sqliteAccountRepo.List: failed to list accounts: %v", err)
+	}
+	defer rows.Close()
+
+	result := []accountModel.Account{}
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("sqliteAccountRepo.List: failed to scan account: %v", err)
+		}
+		result = append(result, *account)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqliteAccountRepo.List: failed to list accounts: %v", err)
+	}
+
+	return result, nil
+}
+
+// RecordGame rates the accounts in the ranking within a single transaction,
+// unknown accounts are left out
+func (r *sqliteAccountRepo) RecordGame(ranking []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("sqliteAccountRepo.RecordGame: failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	accounts := []*accountModel.Account{}
+	ratings := []int{}
+	for _, accountID := range ranking {
+		row := tx.QueryRow("SELECT "+accountColumns+" FROM accounts WHERE id_account = ?", accountID)
+		account, err := scanAccount(row)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("sqliteAccountRepo.RecordGame: failed to get account: %v", err)
+		}
+
+		accounts = append(accounts, account)
+		ratings = append(ratings, account.Rating)
+	}
+
+	if len(accounts) < 2 {
+		return nil
+	}
+
+	for i, rating := range accountModel.Rate(ratings) {
+		account := accounts[i]
+		account.RecordFinish(rating, i == 0)
+
+		_, err := tx.Exec(
+			"UPDATE accounts SET rating = ?, games_played = ?, wins = ? WHERE id_account = ?",
+			account.Rating,
+			account.GamesPlayed,
+			account.Wins,
+			account.AccountID,
+		)
+		if err != nil {
+			return fmt.Errorf("sqliteAccountRepo.RecordGame: failed to update account: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqliteAccountRepo.RecordGame: failed to commit: %v", err)
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAccount(s scanner) (*accountModel.Account, error) {
+	var result accountModel.Account
+
+	err := s.Scan(
+		&result.AccountID,
+		&result.Username,
+		&result.PasswordHash,
+		&result.Rating,
+		&result.GamesPlayed,
+		&result.Wins,
+		&result.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	accountModel "github.com/aryuuu/cepex-server/models/account"
+)
+
+func newAccount(id, username string) *accountModel.Account {
+	return &accountModel.Account{
+		AccountID:    id,
+		Username:     username,
+		PasswordHash: "hash",
+		Rating:       accountModel.InitialRating,
+		CreatedAt:    time.Now(),
+	}
+}
+
+func TestSQLiteAccountRepo(t *testing.T) {
+	db, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo, err := NewSQLiteAccountRepo(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	for _, account := range []*accountModel.Account{newAccount("a", "alice"), newAccount("b", "bob")} {
+		if err := repo.Create(account); err != nil {
+			t.Fatalf("failed to create account: %v", err)
+		}
+	}
+
+	if err := repo.Create(newAccount("c", "Alice")); err != accountModel.ErrUsernameTaken {
+		t.Errorf("usernames should be unique regardless of case, got %v", err)
+	}
+
+	if _, err := repo.Get("c"); err != accountModel.ErrAccountNotFound {
+		t.Errorf("missing account should not be found, got %v", err)
+	}
+
+	listed, err := repo.List(10)
+	if err != nil {
+		t.Fatalf("failed to list accounts: %v", err)
+	}
+	if len(listed) != 0 {
+		t.Errorf("accounts that never played should not be ranked, got %v", listed)
+	}
+
+	if err := repo.RecordGame([]string{"b", "guest", "a"}); err != nil {
+		t.Fatalf("failed to record game: %v", err)
+	}
+
+	bob, err := repo.GetByUsername("bob")
+	if err != nil {
+		t.Fatalf("failed to get account: %v", err)
+	}
+	if bob.Rating != 1216 || bob.GamesPlayed != 1 || bob.Wins != 1 {
+		t.Errorf("winner should be rated up, got %#v", bob)
+	}
+
+	listed, err = repo.List(10)
+	if err != nil {
+		t.Fatalf("failed to list accounts: %v", err)
+	}
+	if len(listed) != 2 || listed[0].AccountID != "b" || listed[1].Rating != 1184 {
+		t.Errorf("unexpected ranking %#v", listed)
+	}
+}
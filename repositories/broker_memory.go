@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"sync"
+
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+)
+
+type subscriber struct {
+	handler func(message []byte)
+}
+
+type memoryBroker struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[*subscriber]bool
+}
+
+// NewMemoryBroker delivers messages within the process, it is enough when
+// there is only a single node
+func NewMemoryBroker() gameModel.Broker {
+	result := &memoryBroker{
+		subscribers: make(map[string]map[*subscriber]bool),
+	}
+
+	return result
+}
+
+func (m *memoryBroker) Publish(topic string, message []byte) error {
+	m.mu.RLock()
+	handlers := []func(message []byte){}
+	for s := range m.subscribers[topic] {
+		handlers = append(handlers, s.handler)
+	}
+	m.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(message)
+	}
+
+	return nil
+}
+
+func (m *memoryBroker) Subscribe(topic string, handler func(message []byte)) (func(), error) {
+	s := &subscriber{handler: handler}
+
+	m.mu.Lock()
+	if _, ok := m.subscribers[topic]; !ok {
+		m.subscribers[topic] = make(map[*subscriber]bool)
+	}
+	m.subscribers[topic][s] = true
+	m.mu.Unlock()
+
+	unsubscribe := func() {
+		m.mu.Lock()
+		delete(m.subscribers[topic], s)
+		if len(m.subscribers[topic]) == 0 {
+			delete(m.subscribers, topic)
+		}
+		m.mu.Unlock()
+	}
+
+	return unsubscribe, nil
+}
+
+func (m *memoryBroker) Claim(roomID, nodeID string) (string, error) {
+	return nodeID, nil
+}
+
+func (m *memoryBroker) Release(roomID, nodeID string) error {
+	return nil
+}
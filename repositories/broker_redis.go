@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	ownerKeyPrefix = "cepex:owner:"
+	ownershipTTL   = 30 * time.Second
+)
+
+// releaseScript only gives up the ownership when it still belongs to the node
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type redisBroker struct {
+	client *redis.Client
+}
+
+// NewRedisBroker shares messages and room ownership between nodes through
+// redis pub/sub
+func NewRedisBroker(client *redis.Client) gameModel.Broker {
+	result := &redisBroker{
+		client: client,
+	}
+
+	return result
+}
+
+func (r *redisBroker) Publish(topic string, message []byte) error {
+	if err := r.client.Publish(context.Background(), topic, message).Err(); err != nil {
+		return fmt.Errorf("redisBroker.Publish: failed to publish message: %v", err)
+	}
+
+	return nil
+}
+
+func (r *redisBroker) Subscribe(topic string, handler func(message []byte)) (func(), error) {
+	ctx := context.Background()
+	pubsub := r.client.Subscribe(ctx, topic)
+
+	// wait for the subscription to be confirmed so nothing published from
+	// now on is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("redisBroker.Subscribe: failed to subscribe: %v", err)
+	}
+
+	go func() {
+		for message := range pubsub.Channel() {
+			handler([]byte(message.Payload))
+		}
+	}()
+
+	unsubscribe := func() {
+		if err := pubsub.Close(); err != nil {
+			log.Printf("redisBroker.Subscribe: failed to unsubscribe from %v: %v", topic, err)
+		}
+	}
+
+	return unsubscribe, nil
+}
+
+func (r *redisBroker) Claim(roomID, nodeID string) (string, error) {
+	ctx := context.Background()
+	key := ownerKeyPrefix + roomID
+
+	ok, err := r.client.SetNX(ctx, key, nodeID, ownershipTTL).Result()
+	if err != nil {
+		return "", fmt.Errorf("redisBroker.Claim: failed to claim room: %v", err)
+	}
+	if ok {
+		return nodeID, nil
+	}
+
+	owner, err := r.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		// the previous owner let go in between, try again
+		return r.Claim(roomID, nodeID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("redisBroker.Claim: failed to get owner: %v", err)
+	}
+
+	if owner == nodeID {
+		if err := r.client.Expire(ctx, key, ownershipTTL).Err(); err != nil {
+			return "", fmt.Errorf("redisBroker.Claim: failed to extend ownership: %v", err)
+		}
+	}
+
+	return owner, nil
+}
+
+func (r *redisBroker) Release(roomID, nodeID string) error {
+	if err := releaseScript.Run(context.Background(), r.client, []string{ownerKeyPrefix + roomID}, nodeID).Err(); err != nil {
+		return fmt.Errorf("redisBroker.Release: failed to release room: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+	"github.com/go-redis/redis/v8"
+)
+
+func testBrokerPubSub(t *testing.T, broker gameModel.Broker) {
+	received := make(chan string, 1)
+	unsubscribe, err := broker.Subscribe("room:ROOM1", func(message []byte) {
+		received <- string(message)
+	})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	if err := broker.Publish("room:ROOM1", []byte("hello")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	select {
+	case message := <-received:
+		if message != "hello" {
+			t.Errorf("expected hello, got %v", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("message was never delivered")
+	}
+
+	unsubscribe()
+}
+
+func TestMemoryBroker(t *testing.T) {
+	testBrokerPubSub(t, NewMemoryBroker())
+}
+
+func TestRedisBroker(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	broker := NewRedisBroker(client)
+	testBrokerPubSub(t, broker)
+
+	owner, err := broker.Claim("ROOM1", "node-1")
+	if err != nil || owner != "node-1" {
+		t.Fatalf("node-1 should own the room, got %v %v", owner, err)
+	}
+
+	owner, err = broker.Claim("ROOM1", "node-2")
+	if err != nil || owner != "node-1" {
+		t.Fatalf("node-1 should still own the room, got %v %v", owner, err)
+	}
+
+	if err := broker.Release("ROOM1", "node-2"); err != nil {
+		t.Fatalf("failed to release room: %v", err)
+	}
+	owner, _ = broker.Claim("ROOM1", "node-2")
+	if owner != "node-1" {
+		t.Fatalf("only the owner should be able to release the room")
+	}
+
+	if err := broker.Release("ROOM1", "node-1"); err != nil {
+		t.Fatalf("failed to release room: %v", err)
+	}
+	owner, _ = broker.Claim("ROOM1", "node-2")
+	if owner != "node-2" {
+		t.Fatalf("node-2 should own the room once released, got %v", owner)
+	}
+
+	server.FastForward(ownershipTTL + time.Second)
+	owner, err = broker.Claim("ROOM1", "node-2")
+	if err != nil || owner != "node-2" {
+		t.Fatalf("node-2 should claim the room again once its claim lapsed, got %v %v", owner, err)
+	}
+
+	server.FastForward(ownershipTTL + time.Second)
+	broker.Claim("ROOM1", "node-3")
+	owner, err = broker.Claim("ROOM1", "node-2")
+	if err != nil || owner != "node-3" {
+		t.Fatalf("node-2 should learn that node-3 took the lapsed room, got %v %v", owner, err)
+	}
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"encoding/json"
+
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+)
+
+// encodeLogHeader encodes everything about a running game but its entries,
+// those are stored one by one as they come
+func encodeLogHeader(gameLog *gameModel.GameLog) ([]byte, error) {
+	header := *gameLog
+	header.Entries = nil
+
+	return json.Marshal(header)
+}
+
+func decodeRunningLog(header []byte, entries [][]byte) (*gameModel.GameLog, error) {
+	var gameLog gameModel.GameLog
+	if err := json.Unmarshal(header, &gameLog); err != nil {
+		return nil, err
+	}
+
+	gameLog.Entries = []gameModel.LogEntry{}
+	for _, data := range entries {
+		var entry gameModel.LogEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, err
+		}
+		gameLog.Entries = append(gameLog.Entries, entry)
+	}
+
+	return &gameLog, nil
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+)
+
+type memoryReplayRepo struct {
+	mu      sync.RWMutex
+	replays map[string][]byte
+	headers map[string][]byte
+	entries map[string][][]byte
+}
+
+// NewMemoryReplayRepo keeps game logs in process memory, they are gone once
+// the server stops
+func NewMemoryReplayRepo() gameModel.ReplayRepository {
+	result := &memoryReplayRepo{
+		replays: make(map[string][]byte),
+		headers: make(map[string][]byte),
+		entries: make(map[string][][]byte),
+	}
+
+	return result
+}
+
+func (m *memoryReplayRepo) Get(roomID, gameID string) (*gameModel.GameLog, error) {
+	m.mu.RLock()
+	data, ok := m.replays[replayKey(roomID, gameID)]
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil, gameModel.ErrReplayNotFound
+	}
+
+	var gameLog gameModel.GameLog
+	if err := json.Unmarshal(data, &gameLog); err != nil {
+		return nil, fmt.Errorf("memoryReplayRepo.Get: failed to decode replay: %v", err)
+	}
+
+	return &gameLog, nil
+}
+
+func (m *memoryReplayRepo) Save(gameLog *gameModel.GameLog) error {
+	data, err := json.Marshal(gameLog)
+	if err != nil {
+		return fmt.Errorf("memoryReplayRepo.Save: failed to encode replay: %v", err)
+	}
+
+	m.mu.Lock()
+	m.replays[replayKey(gameLog.RoomID, gameLog.GameID)] = data
+	delete(m.headers, gameLog.RoomID)
+	delete(m.entries, gameLog.RoomID)
+	m.mu.Unlock()
+
+	return nil
+}
+
+func (m *memoryReplayRepo) Begin(gameLog *gameModel.GameLog) error {
+	data, err := encodeLogHeader(gameLog)
+	if err != nil {
+		return fmt.Errorf("memoryReplayRepo.Begin: failed to encode game log: %v", err)
+	}
+
+	m.mu.Lock()
+	m.headers[gameLog.RoomID] = data
+	m.entries[gameLog.RoomID] = [][]byte{}
+	m.mu.Unlock()
+
+	return nil
+}
+
+func (m *memoryReplayRepo) Append(roomID string, entry gameModel.LogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("memoryReplayRepo.Append: failed to encode entry: %v", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.headers[roomID]; !ok {
+		return gameModel.ErrReplayNotFound
+	}
+	m.entries[roomID] = append(m.entries[roomID], data)
+
+	return nil
+}
+
+func (m *memoryReplayRepo) Running(roomID string) (*gameModel.GameLog, error) {
+	m.mu.RLock()
+	header, ok := m.headers[roomID]
+	entries := m.entries[roomID]
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil, gameModel.ErrReplayNotFound
+	}
+
+	gameLog, err := decodeRunningLog(header, entries)
+	if err != nil {
+		return nil, fmt.Errorf("memoryReplayRepo.Running: failed to decode game log: %v", err)
+	}
+
+	return gameLog, nil
+}
+
+func (m *memoryReplayRepo) Discard(roomID string) error {
+	m.mu.Lock()
+	delete(m.headers, roomID)
+	delete(m.entries, roomID)
+	m.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	replayKeyPrefix = "cepex:replay:"
+	replayTTL       = 7 * 24 * time.Hour
+
+	// running logs are kept apart from cepex:room:* so that listing rooms
+	// never reads them
+	runningLogKeyPrefix = "cepex:game-log:"
+)
+
+type redisReplayRepo struct {
+	client *redis.Client
+}
+
+// NewRedisReplayRepo keeps game logs in anything that speaks the redis
+// protocol for a week
+func NewRedisReplayRepo(client *redis.Client) gameModel.ReplayRepository {
+	result := &redisReplayRepo{
+		client: client,
+	}
+
+	return result
+}
+
+func (r *redisReplayRepo) Get(roomID, gameID string) (*gameModel.GameLog, error) {
+	data, err := r.client.Get(context.Background(), replayKeyPrefix+replayKey(roomID, gameID)).Bytes()
+	if err == redis.Nil {
+		return nil, gameModel.ErrReplayNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redisReplayRepo.Get: failed to get replay: %v", err)
+	}
+
+	var gameLog gameModel.GameLog
+	if err := json.Unmarshal(data, &gameLog); err != nil {
+		return nil, fmt.Errorf("redisReplayRepo.Get: failed to decode replay: %v", err)
+	}
+
+	return &gameLog, nil
+}
+
+func (r *redisReplayRepo) Save(gameLog *gameModel.GameLog) error {
+	data, err := json.Marshal(gameLog)
+	if err != nil {
+		return fmt.Errorf("redisReplayRepo.Save: failed to encode replay: %v", err)
+	}
+
+	ctx := context.Background()
+	key := replayKeyPrefix + replayKey(gameLog.RoomID, gameLog.GameID)
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, data, replayTTL)
+		pipe.Del(ctx, runningLogKeyPrefix+gameLog.RoomID, runningEntriesKey(gameLog.RoomID))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redisReplayRepo.Save: failed to save replay: %v", err)
+	}
+
+	return nil
+}
+
+func (r *redisReplayRepo) Begin(gameLog *gameModel.GameLog) error {
+	ctx := context.Background()
+
+	data, err := encodeLogHeader(gameLog)
+	if err != nil {
+		return fmt.Errorf("redisReplayRepo.Begin: failed to encode game log: %v", err)
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, runningLogKeyPrefix+gameLog.RoomID, data, roomTTL)
+		pipe.Del(ctx, runningEntriesKey(gameLog.RoomID))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redisReplayRepo.Begin: failed to save game log: %v", err)
+	}
+
+	return nil
+}
+
+func (r *redisReplayRepo) Append(roomID string, entry gameModel.LogEntry) error {
+	ctx := context.Background()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("redisReplayRepo.Append: failed to encode entry: %v", err)
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, runningEntriesKey(roomID), data)
+		pipe.Expire(ctx, runningEntriesKey(roomID), roomTTL)
+		pipe.Expire(ctx, runningLogKeyPrefix+roomID, roomTTL)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redisReplayRepo.Append: failed to append entry: %v", err)
+	}
+
+	return nil
+}
+
+func (r *redisReplayRepo) Running(roomID string) (*gameModel.GameLog, error) {
+	ctx := context.Background()
+
+	header, err := r.client.Get(ctx, runningLogKeyPrefix+roomID).Bytes()
+	if err == redis.Nil {
+		return nil, gameModel.ErrReplayNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redisReplayRepo.Running: failed to get game log: %v", err)
+	}
+
+	values, err := r.client.LRange(ctx, runningEntriesKey(roomID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redisReplayRepo.Running: failed to get entries: %v", err)
+	}
+
+	entries := [][]byte{}
+	for _, value := range values {
+		entries = append(entries, []byte(value))
+	}
+
+	gameLog, err := decodeRunningLog(header, entries)
+	if err != nil {
+		return nil, fmt.Errorf("redisReplayRepo.Running: failed to decode game log: %v", err)
+	}
+
+	return gameLog, nil
+}
+
+func (r *redisReplayRepo) Discard(roomID string) error {
+	if err := r.client.Del(context.Background(), runningLogKeyPrefix+roomID, runningEntriesKey(roomID)).Err(); err != nil {
+		return fmt.Errorf("redisReplayRepo.Discard: failed to delete game log: %v", err)
+	}
+
+	return nil
+}
+
+func replayKey(roomID, gameID string) string {
+	return roomID + ":" + gameID
+}
+
+func runningEntriesKey(roomID string) string {
+	return runningLogKeyPrefix + roomID + ":entries"
+}
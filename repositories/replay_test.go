@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+	"github.com/go-redis/redis/v8"
+)
+
+func testReplayRepository(t *testing.T, repo gameModel.ReplayRepository) {
+	room := newStartedRoom()
+	gameLog := gameModel.NewGameLog("GAME1", room)
+	gameLog.AddCommand(gameModel.PlayCardAction, room.TurnID, gameModel.Move{HandIndex: 1, IsAdd: true})
+	gameLog.AddBroadcast("play-card-broadcast", json.RawMessage(`{"count":1}`))
+
+	if _, err := repo.Get(room.RoomID, gameLog.GameID); err != gameModel.ErrReplayNotFound {
+		t.Errorf("unsaved replay should not be found, got %v", err)
+	}
+
+	if err := repo.Save(gameLog); err != nil {
+		t.Fatalf("failed to save replay: %v", err)
+	}
+
+	restored, err := repo.Get(room.RoomID, gameLog.GameID)
+	if err != nil {
+		t.Fatalf("failed to get replay: %v", err)
+	}
+
+	exp, _ := json.Marshal(gameLog)
+	got, _ := json.Marshal(restored)
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("restored replay differs\n\texp: %s\n\tgot: %s", exp, got)
+	}
+}
+
+func testRunningLog(t *testing.T, repo gameModel.ReplayRepository) {
+	room := newStartedRoom()
+	gameLog := gameModel.NewGameLog("GAME1", room)
+
+	if _, err := repo.Running(room.RoomID); err != gameModel.ErrReplayNotFound {
+		t.Errorf("a room without a game should have no running log, got %v", err)
+	}
+
+	if err := repo.Begin(gameLog); err != nil {
+		t.Fatalf("failed to begin game log: %v", err)
+	}
+
+	entry, _ := gameLog.AddCommand(gameModel.PlayCardAction, room.TurnID, gameModel.Move{HandIndex: 1, IsAdd: true})
+	if err := repo.Append(room.RoomID, entry); err != nil {
+		t.Fatalf("failed to append command: %v", err)
+	}
+	entry, _ = gameLog.AddBroadcast("play-card-broadcast", json.RawMessage(`{"count":1}`))
+	if err := repo.Append(room.RoomID, entry); err != nil {
+		t.Fatalf("failed to append broadcast: %v", err)
+	}
+
+	restored, err := repo.Running(room.RoomID)
+	if err != nil {
+		t.Fatalf("failed to get running log: %v", err)
+	}
+
+	exp, _ := json.Marshal(gameLog)
+	got, _ := json.Marshal(restored)
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("running log differs\n\texp: %s\n\tgot: %s", exp, got)
+	}
+
+	if err := repo.Save(gameLog); err != nil {
+		t.Fatalf("failed to save replay: %v", err)
+	}
+
+	if _, err := repo.Running(room.RoomID); err != gameModel.ErrReplayNotFound {
+		t.Errorf("saving the replay should drop the running log, got %v", err)
+	}
+
+	repo.Begin(gameLog)
+	if err := repo.Discard(room.RoomID); err != nil {
+		t.Fatalf("failed to discard running log: %v", err)
+	}
+
+	if _, err := repo.Running(room.RoomID); err != gameModel.ErrReplayNotFound {
+		t.Errorf("discarded running log should not be found, got %v", err)
+	}
+}
+
+func TestMemoryReplayRepo(t *testing.T) {
+	testReplayRepository(t, NewMemoryReplayRepo())
+	testRunningLog(t, NewMemoryReplayRepo())
+}
+
+func TestRedisReplayRepo(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	repo := NewRedisReplayRepo(client)
+	testReplayRepository(t, repo)
+	testRunningLog(t, repo)
+
+	repo.Begin(gameModel.NewGameLog("GAME2", newStartedRoom()))
+	for _, key := range server.Keys() {
+		if strings.HasPrefix(key, roomKeyPrefix) {
+			t.Errorf("game logs should be kept out of the room keys, found %v", key)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"encoding/json"
+	"time"
+
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+)
+
+// roomRecord carries the parts of a room that are hidden from clients but
+// still needed to bring a running game back
+type roomRecord struct {
+	*gameModel.Room
+	Players      []playerRecord                       `json:"players"`
+	Spectators   []playerRecord                       `json:"spectators,omitempty"`
+	Deck         []gameModel.Card                     `json:"deck"`
+	DiscardPile  []gameModel.Card                     `json:"discard_pile"`
+	VoteBallot   map[string]*gameModel.VoteKick       `json:"vote_ballot"`
+	Leaderboard  map[string]gameModel.LeaderboardItem `json:"leaderboard"`
+	Seed         int64                                `json:"seed"`
+	Draws        uint64                               `json:"draws"`
+	Password     string                               `json:"password,omitempty"`
+	Eliminations []string                             `json:"eliminations"`
+	Leavers      map[string]*gameModel.Player         `json:"leavers,omitempty"`
+	Reservations map[string]time.Time                 `json:"reservations,omitempty"`
+	Bans         []gameModel.Ban                      `json:"bans,omitempty"`
+}
+
+type playerRecord struct {
+	*gameModel.Player
+	Hand []gameModel.Card `json:"hand"`
+	IP   string           `json:"ip,omitempty"`
+}
+
+func encodeRoom(room *gameModel.Room) ([]byte, error) {
+	record := roomRecord{
+		Room:         room,
+		Players:      []playerRecord{},
+		Deck:         room.Deck,
+		DiscardPile:  room.DiscardPile,
+		VoteBallot:   room.VoteBallot,
+		Leaderboard:  room.Leaderboard,
+		Seed:         room.Seed,
+		Draws:        room.Draws(),
+		Password:     room.Password,
+		Eliminations: room.Eliminations,
+		Leavers:      room.Leavers,
+		Reservations: room.Reservations,
+		Bans:         room.Bans,
+	}
+
+	for _, p := range room.Players {
+		record.Players = append(record.Players, playerRecord{Player: p, Hand: p.Hand, IP: p.IP})
+	}
+
+	for _, spectator := range room.Spectators {
+		record.Spectators = append(record.Spectators, playerRecord{Player: spectator, Hand: []gameModel.Card{}, IP: spectator.IP})
+	}
+
+	return json.Marshal(record)
+}
+
+func decodeRoom(data []byte) (*gameModel.Room, error) {
+	var record roomRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+
+	room := record.Room
+	room.Players = []*gameModel.Player{}
+	room.PlayerMap = make(map[string]*gameModel.Player)
+	for _, p := range record.Players {
+		p.Player.Hand = p.Hand
+		p.Player.IP = p.IP
+		if p.Player.Hand == nil {
+			p.Player.Hand = []gameModel.Card{}
+		}
+		room.AddPlayer(p.Player)
+	}
+
+	room.Spectators = nil
+	for _, p := range record.Spectators {
+		p.Player.Hand = []gameModel.Card{}
+		p.Player.IP = p.IP
+		room.Spectators = append(room.Spectators, p.Player)
+	}
+
+	// rooms saved before rule sets existed play by the classic rules
+	if room.Rules.Effects == nil {
+		room.Rules, _ = gameModel.NewRuleSet(room.Settings)
+	}
+
+	room.Deck = record.Deck
+	room.DiscardPile = record.DiscardPile
+	room.RestoreRandom(record.Seed, record.Draws)
+	room.Password = record.Password
+	room.Eliminations = record.Eliminations
+	room.Leavers = record.Leavers
+	if room.Leavers == nil {
+		room.Leavers = make(map[string]*gameModel.Player)
+	}
+	room.Reservations = record.Reservations
+	if room.Reservations == nil {
+		room.Reservations = make(map[string]time.Time)
+	}
+	room.Bans = record.Bans
+	room.VoteBallot = record.VoteBallot
+	if room.VoteBallot == nil {
+		room.VoteBallot = make(map[string]*gameModel.VoteKick)
+	}
+	room.Leaderboard = record.Leaderboard
+	if room.Leaderboard == nil {
+		room.Leaderboard = make(map[string]gameModel.LeaderboardItem)
+	}
+
+	return room, nil
+}
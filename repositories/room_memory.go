@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"fmt"
+	"sync"
+
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+)
+
+type memoryRoomRepo struct {
+	mu    sync.RWMutex
+	rooms map[string][]byte
+}
+
+// NewMemoryRoomRepo keeps rooms in process memory, they are gone once the
+// server stops
+func NewMemoryRoomRepo() gameModel.RoomRepository {
+	result := &memoryRoomRepo{
+		rooms: make(map[string][]byte),
+	}
+
+	return result
+}
+
+func (m *memoryRoomRepo) Get(roomID string) (*gameModel.Room, error) {
+	m.mu.RLock()
+	data, ok := m.rooms[roomID]
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil, gameModel.ErrRoomNotFound
+	}
+
+	room, err := decodeRoom(data)
+	if err != nil {
+		return nil, fmt.Errorf("memoryRoomRepo.Get: failed to decode room: %v", err)
+	}
+
+	return room, nil
+}
+
+func (m *memoryRoomRepo) List() ([]*gameModel.Room, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := []*gameModel.Room{}
+	for _, data := range m.rooms {
+		room, err := decodeRoom(data)
+		if err != nil {
+			return nil, fmt.Errorf("memoryRoomRepo.List: failed to decode room: %v", err)
+		}
+		result = append(result, room)
+	}
+
+	return result, nil
+}
+
+func (m *memoryRoomRepo) Save(room *gameModel.Room) error {
+	data, err := encodeRoom(room)
+	if err != nil {
+		return fmt.Errorf("memoryRoomRepo.Save: failed to encode room: %v", err)
+	}
+
+	m.mu.Lock()
+	m.rooms[room.RoomID] = data
+	m.mu.Unlock()
+
+	return nil
+}
+
+func (m *memoryRoomRepo) Delete(roomID string) error {
+	m.mu.Lock()
+	delete(m.rooms, roomID)
+	m.mu.Unlock()
+
+	return nil
+}
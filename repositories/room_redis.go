@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	roomKeyPrefix = "cepex:room:"
+	roomTTL       = 24 * time.Hour
+)
+
+type redisRoomRepo struct {
+	client *redis.Client
+}
+
+// NewRedisRoomRepo keeps rooms in anything that speaks the redis protocol,
+// rooms that are left untouched for a day are dropped
+func NewRedisRoomRepo(client *redis.Client) gameModel.RoomRepository {
+	result := &redisRoomRepo{
+		client: client,
+	}
+
+	return result
+}
+
+func (r *redisRoomRepo) Get(roomID string) (*gameModel.Room, error) {
+	data, err := r.client.Get(context.Background(), roomKeyPrefix+roomID).Bytes()
+	if err == redis.Nil {
+		return nil, gameModel.ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redisRoomRepo.Get: failed to get room: %v", err)
+	}
+
+	room, err := decodeRoom(data)
+	if err != nil {
+		return nil, fmt.Errorf("redisRoomRepo.Get: failed to decode room: %v", err)
+	}
+
+	return room, nil
+}
+
+func (r *redisRoomRepo) List() ([]*gameModel.Room, error) {
+	ctx := context.Background()
+	result := []*gameModel.Room{}
+
+	iter := r.client.Scan(ctx, 0, roomKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		data, err := r.client.Get(ctx, iter.Val()).Bytes()
+		if err == redis.Nil {
+			// the room has been deleted while scanning
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("redisRoomRepo.List: failed to get room: %v", err)
+		}
+
+		room, err := decodeRoom(data)
+		if err != nil {
+			return nil, fmt.Errorf("redisRoomRepo.List: failed to decode room: %v", err)
+		}
+		result = append(result, room)
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("redisRoomRepo.List: failed to scan rooms: %v", err)
+	}
+
+	return result, nil
+}
+
+func (r *redisRoomRepo) Save(room *gameModel.Room) error {
+	data, err := encodeRoom(room)
+	if err != nil {
+		return fmt.Errorf("redisRoomRepo.Save: failed to encode room: %v", err)
+	}
+
+	if err := r.client.Set(context.Background(), roomKeyPrefix+room.RoomID, data, roomTTL).Err(); err != nil {
+		return fmt.Errorf("redisRoomRepo.Save: failed to save room: %v", err)
+	}
+
+	return nil
+}
+
+func (r *redisRoomRepo) Delete(roomID string) error {
+	if err := r.client.Del(context.Background(), roomKeyPrefix+roomID).Err(); err != nil {
+		return fmt.Errorf("redisRoomRepo.Delete: failed to delete room: %v", err)
+	}
+
+	return nil
+}
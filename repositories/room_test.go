@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+	"github.com/go-redis/redis/v8"
+)
+
+func newStartedRoom() *gameModel.Room {
+	player1 := gameModel.NewPlayer("player1", "")
+	player2 := gameModel.NewPlayer("player2", "")
+	room := gameModel.NewRoom("ROOM1", player1.PlayerID, 4)
+	room.AddPlayer(player1)
+	room.AddPlayer(player2)
+	player2.IP = "10.0.0.2"
+	spectator := gameModel.NewPlayer("spectator1", "")
+	spectator.IP = "10.0.0.3"
+	room.AddSpectator(spectator)
+	room.UpdateSettings(gameModel.Settings{Capacity: 3, Password: "secret"})
+	room.StartGame()
+	room.StartVoteKick(player1.PlayerID, player2.PlayerID)
+	room.Leaderboard[player1.PlayerID] = gameModel.LeaderboardItem{PlayerID: player1.PlayerID, Score: 3}
+
+	return room
+}
+
+func testRoomRepository(t *testing.T, repo gameModel.RoomRepository) {
+	room := newStartedRoom()
+
+	if err := repo.Save(room); err != nil {
+		t.Fatalf("failed to save room: %v", err)
+	}
+
+	restored, err := repo.Get(room.RoomID)
+	if err != nil {
+		t.Fatalf("failed to get room: %v", err)
+	}
+
+	if !reflect.DeepEqual(room, restored) {
+		t.Errorf("restored room differs\n\texp: %#v\n\tgot: %#v", room, restored)
+	}
+
+	rooms, err := repo.List()
+	if err != nil {
+		t.Fatalf("failed to list rooms: %v", err)
+	}
+
+	if len(rooms) != 1 || !reflect.DeepEqual(room, rooms[0]) {
+		t.Errorf("listed rooms differ\n\texp: %#v\n\tgot: %#v", []*gameModel.Room{room}, rooms)
+	}
+
+	if restored.PlayerMap[room.TurnID] != restored.Players[restored.GetPlayerIndex(room.TurnID)] {
+		t.Errorf("player map should point to the same players as the player list")
+	}
+
+	if err := repo.Delete(room.RoomID); err != nil {
+		t.Fatalf("failed to delete room: %v", err)
+	}
+
+	if _, err := repo.Get(room.RoomID); err != gameModel.ErrRoomNotFound {
+		t.Errorf("deleted room should not be found, got %v", err)
+	}
+}
+
+func TestMemoryRoomRepo(t *testing.T) {
+	testRoomRepository(t, NewMemoryRoomRepo())
+}
+
+func TestRedisRoomRepo(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	testRoomRepository(t, NewRedisRoomRepo(client))
+}
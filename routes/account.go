@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	accountModel "github.com/aryuuu/cepex-server/models/account"
+	"github.com/gorilla/mux"
+)
+
+type AccountRouter struct {
+	AccountUsecase accountModel.AccountUsecase
+}
+
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type session struct {
+	Account *accountModel.Account `json:"account"`
+	Token   string                `json:"token"`
+}
+
+// InitAccountRouter :nodoc:
+func InitAccountRouter(r *mux.Router, auc accountModel.AccountUsecase) {
+	accountRouter := &AccountRouter{
+		AccountUsecase: auc,
+	}
+
+	r.HandleFunc("/account/register", accountRouter.HandleRegister).Methods("POST")
+	r.HandleFunc("/account/login", accountRouter.HandleLogin).Methods("POST")
+	r.HandleFunc("/leaderboard", accountRouter.HandleLeaderboard).Methods("GET")
+}
+
+func (m AccountRouter) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	var body credentials
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, "Invalid request body")
+		return
+	}
+
+	account, token, err := m.AccountUsecase.Register(body.Username, body.Password)
+	switch err {
+	case nil:
+	case accountModel.ErrInvalidCredentials:
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, err.Error())
+		return
+	case accountModel.ErrUsernameTaken:
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, err.Error())
+		return
+	default:
+		log.Printf("AccountRouter.HandleRegister: error registering account: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "Failed to register")
+		return
+	}
+
+	writeSession(w, http.StatusCreated, account, token)
+}
+
+func (m AccountRouter) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	var body credentials
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, "Invalid request body")
+		return
+	}
+
+	account, token, err := m.AccountUsecase.Login(body.Username, body.Password)
+	if err == accountModel.ErrWrongCredentials {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("AccountRouter.HandleLogin: error logging in: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "Failed to log in")
+		return
+	}
+
+	writeSession(w, http.StatusOK, account, token)
+}
+
+func (m AccountRouter) HandleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	result, err := m.AccountUsecase.GetLeaderboard(limit)
+	if err != nil {
+		log.Printf("AccountRouter.HandleLeaderboard: error getting leaderboard: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "Failed to get leaderboard")
+		return
+	}
+
+	body := struct {
+		Data []accountModel.Account `json:"data"`
+	}{
+		Data: result,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeSession(w http.ResponseWriter, status int, account *accountModel.Account, token string) {
+	body := struct {
+		Data session `json:"data"`
+	}{
+		Data: session{Account: account, Token: token},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
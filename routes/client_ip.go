@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// NewClientIPResolver tells where a request comes from. X-Forwarded-For is
+// only believed when the request is made by one of the trusted proxies,
+// given as addresses or CIDR ranges, anyone else could forge it
+func NewClientIPResolver(trustedProxies []string) func(r *http.Request) string {
+	networks := []*net.IPNet{}
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			log.Printf("ignoring invalid trusted proxy %q: %v", proxy, err)
+			continue
+		}
+		networks = append(networks, network)
+	}
+
+	isTrusted := func(address string) bool {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return false
+		}
+		for _, network := range networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(r *http.Request) string {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		if !isTrusted(host) {
+			return host
+		}
+
+		// every proxy appends who it got the request from, the first
+		// address not added by a trusted proxy is the client
+		hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop == "" {
+				break
+			}
+			if !isTrusted(hop) {
+				return hop
+			}
+			host = hop
+		}
+
+		return host
+	}
+}
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	resolve := NewClientIPResolver([]string{"10.0.0.0/8", "192.168.1.1", "fd00::1", "not a proxy"})
+
+	cases := []struct {
+		remoteAddr   string
+		forwardedFor string
+		expectedIP   string
+		name         string
+	}{
+		{"203.0.113.7:4000", "", "203.0.113.7", "direct client"},
+		{"203.0.113.7:4000", "198.51.100.1", "203.0.113.7", "spoofed header from an untrusted peer"},
+		{"203.0.113.7:4000", "10.0.0.5", "203.0.113.7", "untrusted peer posing as a proxy"},
+		{"10.0.0.2:4000", "198.51.100.1", "198.51.100.1", "client behind a trusted proxy"},
+		{"192.168.1.1:4000", "198.51.100.1", "198.51.100.1", "client behind a single trusted address"},
+		{"10.0.0.2:4000", "1.2.3.4, 198.51.100.1", "198.51.100.1", "client prepends a forged hop"},
+		{"10.0.0.2:4000", "198.51.100.1, 10.0.0.9", "198.51.100.1", "client behind a chain of trusted proxies"},
+		{"10.0.0.2:4000", "10.0.0.9", "10.0.0.9", "only trusted hops"},
+		{"10.0.0.2:4000", "", "10.0.0.2", "trusted proxy without the header"},
+		{"192.168.1.2:4000", "198.51.100.1", "192.168.1.2", "address next to a trusted one"},
+		{"[fd00::1]:4000", "2001:db8::7", "2001:db8::7", "trusted IPv6 proxy"},
+		{"[fd00::2]:4000", "2001:db8::7", "fd00::2", "untrusted IPv6 peer"},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/game/ROOM1", nil)
+		r.RemoteAddr = c.remoteAddr
+		if c.forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", c.forwardedFor)
+		}
+
+		if ip := resolve(r); ip != c.expectedIP {
+			t.Errorf("%v: expected %v, got %v", c.name, c.expectedIP, ip)
+		}
+	}
+}
@@ -1,11 +1,15 @@
 package routes
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
+	accountModel "github.com/aryuuu/cepex-server/models/account"
 	gameModel "github.com/aryuuu/cepex-server/models/game"
+	"github.com/aryuuu/cepex-server/utils/codec"
 	"github.com/aryuuu/cepex-server/utils/common"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -16,19 +20,29 @@ type GameRouter struct {
 	Rooms       map[string]map[*websocket.Conn]string
 	GameRooms   map[string]*gameModel.Room
 	GameUsecase gameModel.GameUsecase
+	// AccountUsecase is nil when accounts are disabled, everyone plays as a
+	// guest then
+	AccountUsecase accountModel.AccountUsecase
+	// ClientIP tells where a request comes from, bans are enforced on it
+	ClientIP func(r *http.Request) string
 }
 
-func InitGameRouter(r *mux.Router, upgrader websocket.Upgrader, guc gameModel.GameUsecase) {
+func InitGameRouter(r *mux.Router, upgrader websocket.Upgrader, clientIP func(r *http.Request) string, guc gameModel.GameUsecase, auc accountModel.AccountUsecase) {
 	gameRouter := &GameRouter{
-		Upgrader:    upgrader,
-		Rooms:       make(map[string]map[*websocket.Conn]string),
-		GameRooms:   make(map[string]*gameModel.Room),
-		GameUsecase: guc,
+		Upgrader:       upgrader,
+		ClientIP:       clientIP,
+		Rooms:          make(map[string]map[*websocket.Conn]string),
+		GameRooms:      make(map[string]*gameModel.Room),
+		GameUsecase:    guc,
+		AccountUsecase: auc,
 	}
 
-	go gameRouter.GameUsecase.RunSwitch()
-
 	r.HandleFunc("/create", gameRouter.HandleCreateRoom)
+	r.HandleFunc("/rooms", gameRouter.HandleListRooms).Methods("GET")
+	r.HandleFunc("/lobby", gameRouter.HandleLobby)
+	r.HandleFunc("/schema.proto", gameRouter.HandleGetSchema).Methods("GET")
+	r.HandleFunc("/{roomID}/replays/{gameID}", gameRouter.HandleGetReplay).Methods("GET")
+	r.HandleFunc("/{roomID}/ticket", gameRouter.HandleIssueTicket).Methods("POST")
 	r.HandleFunc("/{roomID}", gameRouter.HandleGameEvent)
 }
 
@@ -40,15 +54,151 @@ func (m GameRouter) HandleCreateRoom(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "%s", ID)
 }
 
+func (m GameRouter) HandleListRooms(w http.ResponseWriter, r *http.Request) {
+	result, err := m.GameUsecase.ListRooms()
+	if err != nil {
+		log.Printf("GameRouter.HandleListRooms: error listing rooms: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "Failed to list rooms")
+		return
+	}
+
+	body := struct {
+		Data []gameModel.RoomSummary `json:"data"`
+	}{
+		Data: result,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body)
+}
+
+func (m GameRouter) HandleLobby(w http.ResponseWriter, r *http.Request) {
+	conn, err := m.Upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	m.GameUsecase.ConnectLobby(conn)
+}
+
 func (m GameRouter) HandleGameEvent(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	roomID := vars["roomID"]
 
+	// browsers can not set headers on a websocket upgrade, hence the query
+	ticket := r.URL.Query().Get("ticket")
+	if ticket == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, "A ticket is required to connect")
+		return
+	}
+
+	identity, err := m.GameUsecase.VerifyTicket(roomID, ticket)
+	if err == gameModel.ErrWrongRoom {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, err.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, err.Error())
+		return
+	}
+
+	identity.IP = m.ClientIP(r)
+
 	conn, err := m.Upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Print(err)
 		return
 	}
 
-	m.GameUsecase.Connect(conn, roomID)
+	m.GameUsecase.Connect(conn, roomID, identity)
+}
+
+// HandleIssueTicket hands out the ticket needed to connect to a room. An
+// account token in the Authorization header ties the ticket to the account,
+// a session token of the room gets the seat it was issued for back
+func (m GameRouter) HandleIssueTicket(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	roomID := vars["roomID"]
+
+	var body struct {
+		SessionToken string `json:"session_token"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Invalid request body")
+			return
+		}
+	}
+
+	accountID := ""
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		if m.AccountUsecase == nil {
+			w.WriteHeader(http.StatusNotImplemented)
+			fmt.Fprint(w, "Accounts are disabled")
+			return
+		}
+
+		var err error
+		accountID, err = m.AccountUsecase.Authenticate(strings.TrimPrefix(header, "Bearer "))
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "Invalid account token")
+			return
+		}
+	}
+
+	result, err := m.GameUsecase.IssueTicket(roomID, body.SessionToken, accountID)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, "Invalid session token")
+		return
+	}
+
+	data := struct {
+		Data *gameModel.Ticket `json:"data"`
+	}{
+		Data: result,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(data)
+}
+
+// HandleGetSchema serves the protobuf schema of the cepex.proto.v1
+// subprotocol
+func (m GameRouter) HandleGetSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, codec.ProtoSchema())
+}
+
+func (m GameRouter) HandleGetReplay(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	roomID := vars["roomID"]
+	gameID := vars["gameID"]
+
+	result, err := m.GameUsecase.GetReplay(roomID, gameID)
+	if err == gameModel.ErrReplayNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "Replay not found")
+		return
+	}
+	if err != nil {
+		log.Printf("GameRouter.HandleGetReplay: error getting replay: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "Failed to get replay")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
 }
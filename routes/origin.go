@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NewOriginChecker only lets browsers from the allowed origins open a
+// websocket. With no origin allowed only the origin of the server itself
+// is, and "*" allows every origin
+func NewOriginChecker(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			// not a browser, there is no page to forge the request from
+			return true
+		}
+
+		if len(allowed) == 0 {
+			u, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(u.Host, r.Host)
+		}
+
+		for _, o := range allowed {
+			if o == "*" || strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
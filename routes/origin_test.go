@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOriginChecker(t *testing.T) {
+	cases := []struct {
+		allowed []string
+		host    string
+		origin  string
+		isOK    bool
+	}{
+		{nil, "cepex.example", "", true},
+		{nil, "cepex.example", "https://cepex.example", true},
+		{nil, "cepex.example", "https://CEPEX.example", true},
+		{nil, "cepex.example", "https://evil.example", false},
+		{nil, "cepex.example", "https://cepex.example.evil.example", false},
+		{[]string{"https://app.example/"}, "api.example", "https://app.example", true},
+		{[]string{"https://app.example"}, "api.example", "http://app.example", false},
+		{[]string{"https://app.example"}, "api.example", "https://api.example", false},
+		{[]string{"https://app.example"}, "api.example", "https://app.example.evil.example", false},
+		{[]string{"https://app.example"}, "api.example", "null", false},
+		{[]string{"https://app.example", "*"}, "api.example", "https://evil.example", true},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "http://"+c.host+"/game/ROOM1", nil)
+		if c.origin != "" {
+			r.Header.Set("Origin", c.origin)
+		}
+
+		if isOK := NewOriginChecker(c.allowed)(r); isOK != c.isOK {
+			t.Errorf("origin %q on %v allowing %v: expected %v, got %v", c.origin, c.host, c.allowed, c.isOK, isOK)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package usecases
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aryuuu/cepex-server/configs"
+	accountModel "github.com/aryuuu/cepex-server/models/account"
+	"github.com/aryuuu/cepex-server/utils/token"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	accountTokenKind = "account"
+	accountTokenTTL  = 30 * 24 * time.Hour
+
+	maxLeaderboardSize = 100
+)
+
+type accountUsecase struct {
+	accountRepo accountModel.AccountRepository
+}
+
+func NewAccountUsecase(ar accountModel.AccountRepository) accountModel.AccountUsecase {
+	return &accountUsecase{
+		accountRepo: ar,
+	}
+}
+
+func (u *accountUsecase) Register(username, password string) (*accountModel.Account, string, error) {
+	username = strings.TrimSpace(username)
+	if len(username) < 3 || len(username) > 32 || len(password) < 8 || len(password) > 72 {
+		return nil, "", accountModel.ErrInvalidCredentials
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", err
+	}
+
+	account := &accountModel.Account{
+		AccountID:    uuid.NewString(),
+		Username:     username,
+		PasswordHash: string(hash),
+		Rating:       accountModel.InitialRating,
+		CreatedAt:    time.Now(),
+	}
+	if err := u.accountRepo.Create(account); err != nil {
+		return nil, "", err
+	}
+
+	return account, u.issueToken(account.AccountID), nil
+}
+
+func (u *accountUsecase) Login(username, password string) (*accountModel.Account, string, error) {
+	account, err := u.accountRepo.GetByUsername(strings.TrimSpace(username))
+	if err == accountModel.ErrAccountNotFound {
+		return nil, "", accountModel.ErrWrongCredentials
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(password)); err != nil {
+		return nil, "", accountModel.ErrWrongCredentials
+	}
+
+	return account, u.issueToken(account.AccountID), nil
+}
+
+// Authenticate tells which account a token has been issued to
+func (u *accountUsecase) Authenticate(signed string) (string, error) {
+	fields, err := token.Verify(configs.Session.Secret, signed)
+	if err != nil || len(fields) != 3 || fields[0] != accountTokenKind {
+		return "", token.ErrInvalidToken
+	}
+
+	expiresAt, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return "", token.ErrInvalidToken
+	}
+
+	return fields[1], nil
+}
+
+func (u *accountUsecase) GetLeaderboard(limit int) ([]accountModel.Account, error) {
+	if limit <= 0 || limit > maxLeaderboardSize {
+		limit = maxLeaderboardSize
+	}
+
+	return u.accountRepo.List(limit)
+}
+
+// RecordGame rates a finished game, ranking lists the accounts in the order
+// they finished, winner first
+func (u *accountUsecase) RecordGame(ranking []string) error {
+	return u.accountRepo.RecordGame(ranking)
+}
+
+func (u *accountUsecase) issueToken(accountID string) string {
+	expiresAt := time.Now().Add(accountTokenTTL).Unix()
+	return token.Sign(configs.Session.Secret, accountTokenKind, accountID, strconv.FormatInt(expiresAt, 10))
+}
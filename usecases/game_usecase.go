@@ -1,481 +1,576 @@
 package usecases
 
 import (
+	"encoding/json"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/aryuuu/cepex-server/configs"
+	accountModel "github.com/aryuuu/cepex-server/models/account"
 	"github.com/aryuuu/cepex-server/models/events"
 	gameModel "github.com/aryuuu/cepex-server/models/game"
+	"github.com/aryuuu/cepex-server/utils/codec"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
+const (
+	// disconnectEvent is raised by the server itself when a connection drops
+	disconnectEvent = "disconnect"
+
+	claimInterval = 10 * time.Second
+)
+
 type connection struct {
-	ID    string
-	Queue chan interface{}
+	ID      string
+	RoomID  string
+	Queue   chan json.RawMessage
+	Replies *replyWindow
+	// Codec speaks the subprotocol negotiated with the client
+	Codec events.Codec
+}
+
+// roomMessage is what a room actor sends out to the connections of its room
+type roomMessage struct {
+	ConnIDs []string        `json:"id_connections"`
+	Message json.RawMessage `json:"message,omitempty"`
+	Close   bool            `json:"close,omitempty"`
+	// RequestID is set when the message answers a request of the connection
+	RequestID string `json:"id_request,omitempty"`
+}
+
+// remoteCommand is a client request forwarded to the node owning the room
+type remoteCommand struct {
+	ConnID  string             `json:"id_connection"`
+	Request events.GameRequest `json:"request"`
+}
+
+type roomSubscription struct {
+	count       int
+	unsubscribe func()
 }
 
 type gameUsecase struct {
-	Rooms       map[string]map[*websocket.Conn]*connection
-	GameRooms   map[string]*gameModel.Room
-	SwitchQueue chan events.SocketEvent
+	nodeID        string
+	mu            sync.Mutex
+	Rooms         map[string]*roomActor
+	conns         map[string]*connection
+	subscriptions map[string]*roomSubscription
+	lobbyConns    map[string]*connection
+	// quickMatches are the quick matches of lobby connections waiting for
+	// a room to hold a seat
+	quickMatches map[string]*quickMatch
+	roomRepo     gameModel.RoomRepository
+	replayRepo   gameModel.ReplayRepository
+	broker       gameModel.Broker
+	accounts     accountModel.AccountUsecase
 }
 
-func NewConnection(ID string) *connection {
+func NewConnection(ID, roomID string, codec events.Codec) *connection {
 	return &connection{
-		ID:    ID,
-		Queue: make(chan interface{}, 256),
+		ID:      ID,
+		RoomID:  roomID,
+		Queue:   make(chan json.RawMessage, 256),
+		Replies: newReplyWindow(),
+		Codec:   codec,
 	}
 }
 
-func NewGameUsecase() gameModel.GameUsecase {
-	return &gameUsecase{
-		Rooms:       make(map[string]map[*websocket.Conn]*connection),
-		GameRooms:   make(map[string]*gameModel.Room),
-		SwitchQueue: make(chan events.SocketEvent, 256),
+// NewGameUsecase runs the rooms, games are only rated when an account
+// usecase is given
+func NewGameUsecase(rr gameModel.RoomRepository, replayRepo gameModel.ReplayRepository, broker gameModel.Broker, auc accountModel.AccountUsecase) gameModel.GameUsecase {
+	u := &gameUsecase{
+		nodeID:        uuid.NewString(),
+		Rooms:         make(map[string]*roomActor),
+		conns:         make(map[string]*connection),
+		subscriptions: make(map[string]*roomSubscription),
+		lobbyConns:    make(map[string]*connection),
+		quickMatches:  make(map[string]*quickMatch),
+		roomRepo:      rr,
+		replayRepo:    replayRepo,
+		broker:        broker,
+		accounts:      auc,
+	}
+
+	if _, err := broker.Subscribe(lobbyTopic, u.deliverLobby); err != nil {
+		log.Printf("failed to subscribe to the lobby: %v", err)
 	}
+
+	go u.keepClaims()
+
+	return u
 }
 
-func (u *gameUsecase) Connect(conn *websocket.Conn, roomID string) {
-	for {
-		var gameRequest events.GameRequest
-		err := conn.ReadJSON(&gameRequest)
+func (u *gameUsecase) Connect(conn *websocket.Conn, roomID string, identity gameModel.Identity) {
+	c := NewConnection(uuid.NewString(), roomID, codec.New(conn.Subprotocol()))
+	if err := u.registerConn(c); err != nil {
+		log.Printf("failed to subscribe to room %v: %v", roomID, err)
+		conn.Close()
+		return
+	}
+	defer u.unregisterConn(c)
+
+	keepAlive(conn)
+	go writePump(conn, c)
 
+	for {
+		_, frame, err := conn.ReadMessage()
 		if err != nil {
 			log.Print(err)
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) {
-				log.Print("IsUnexpectedCloseError()", err)
-				u.kickPlayer(conn, roomID, gameRequest)
-			} else {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
 				log.Printf("expected close error: %v", err)
+				u.dispatch(roomID, c.ID, events.GameRequest{EventType: events.LeaveRoomEvent})
+			} else {
+				log.Printf("connection dropped: %v", err)
+				u.dispatch(roomID, c.ID, events.GameRequest{EventType: disconnectEvent})
 			}
 			return
 		}
-		log.Printf("gameRequest: %v", gameRequest)
-
-		switch gameRequest.EventType {
-		case events.CreateRoomEvent:
-			u.createRoom(conn, roomID, gameRequest)
-		case events.JoinRoomEvent:
-			u.joinRoom(conn, roomID, gameRequest)
-		case events.LeaveRoomEvent:
-			u.kickPlayer(conn, roomID, gameRequest)
-		case events.KickPlayerEvent:
-			u.kickPlayer(conn, roomID, gameRequest)
-		case events.VoteKickPlayerEvent:
-			u.voteKickPlayer(conn, roomID, gameRequest)
-		case events.StartGameEvent:
-			u.startGame(conn, roomID)
-		case events.PlayCardEvent:
-			u.playCard(conn, roomID, gameRequest)
-		case events.ChatEvent:
-			u.broadcastChat(conn, roomID, gameRequest)
-		default:
-		}
-	}
-}
 
-func (u *gameUsecase) createRoom(conn *websocket.Conn, roomID string, gameRequest events.GameRequest) {
-	log.Printf("Client trying to create a new room with ID %v", roomID)
+		extendDeadline(conn)
 
-	if len(u.Rooms) >= int(configs.Constant.Capacity) {
-		message := events.NewCreateRoomResponse(false, roomID, nil, "Server is full")
-		u.pushMessage(false, roomID, conn, message)
-		return
-	}
+		gameRequest, err := decodeRequest(c, frame)
+		if err != nil {
+			log.Printf("rejected request on room %v: %v", roomID, err)
+			u.reject(c, err)
+			continue
+		}
+		log.Printf("gameRequest: %v", gameRequest)
 
-	_, ok := u.Rooms[roomID]
+		if u.isRetry(c, gameRequest.RequestID) {
+			log.Printf("request %v on room %v is a retry", gameRequest.RequestID, roomID)
+			continue
+		}
 
-	if ok {
-		message := events.NewCreateRoomResponse(false, roomID, nil, "Room already exists")
-		u.pushMessage(false, roomID, conn, message)
-		return
+		gameRequest.Identity = identity
+		u.dispatch(roomID, c.ID, gameRequest)
 	}
-
-	player := gameModel.NewPlayer(gameRequest.ClientName, gameRequest.AvatarURL)
-
-	u.createConnectionRoom(roomID, conn)
-	u.createGameRoom(roomID, player.PlayerID)
-	u.registerPlayer(roomID, conn, player)
-
-	res := events.NewCreateRoomResponse(true, roomID, player, "")
-	u.pushMessage(false, roomID, conn, res)
 }
 
-func (u *gameUsecase) joinRoom(conn *websocket.Conn, roomID string, gameRequest events.GameRequest) {
-	log.Printf("Client trying to join room %v", roomID)
-
-	_, ok := u.Rooms[roomID]
-
-	if !ok {
-		log.Printf("room %v does not exist", roomID)
-		res := events.NewJoinRoomResponse(ok, &gameModel.Room{}, "")
-		conn.WriteJSON(res)
-		return
-	}
+func (u *gameUsecase) GetReplay(roomID, gameID string) (*gameModel.GameLog, error) {
+	return u.replayRepo.Get(roomID, gameID)
+}
 
-	log.Printf("found room %v", roomID)
-	gameRoom := u.GameRooms[roomID]
-	if gameRoom.IsUsernameExist(gameRequest.ClientName) {
-		log.Printf("username %s already exist", gameRequest.ClientName)
-		res := events.NewJoinRoomResponse(false, &gameModel.Room{}, "username already exist")
-		conn.WriteJSON(res)
+// dispatch hands a client request over to the actor running the room, be it
+// on this node or on another one
+func (u *gameUsecase) dispatch(roomID, connID string, gameRequest events.GameRequest) {
+	command := newRoomCommand(connID, gameRequest)
+	if command == nil {
 		return
 	}
 
-	player := gameModel.NewPlayer(gameRequest.ClientName, gameRequest.AvatarURL)
-	u.registerPlayer(roomID, conn, player)
-
-	res := events.NewJoinRoomResponse(ok, gameRoom, "")
-	u.pushMessage(false, roomID, conn, res)
-
-	broadcast := events.NewJoinRoomBroadcast(player)
-	u.pushMessage(true, roomID, nil, broadcast)
-}
-
-func (u *gameUsecase) kickPlayer(conn *websocket.Conn, roomID string, gameRequest events.GameRequest) {
-	log.Printf("Client trying to leave room %v", roomID)
-
-	var playerID string
-
-	if gameRequest.PlayerID == "" {
-		player := u.Rooms[roomID][conn]
-		if player != nil {
-			playerID = u.Rooms[roomID][conn].ID
-		}
-	} else {
-		playerID = gameRequest.PlayerID
-		room := u.GameRooms[roomID]
-		if room == nil {
-			res := events.NewVoteKickPlayerResponse(false)
-			u.pushMessage(false, roomID, conn, res)
+	for {
+		actor, err := u.ownRoom(roomID)
+		if err != nil {
+			log.Printf("failed to claim room %v: %v", roomID, err)
 			return
 		}
 
-		_, ok := room.PlayerMap[playerID]
-		if !ok {
-			res := events.NewVoteKickPlayerResponse(false)
-			u.pushMessage(false, roomID, conn, res)
+		if actor == nil {
+			u.forward(roomID, remoteCommand{ConnID: connID, Request: gameRequest})
 			return
 		}
 
-		res := events.NewVoteKickPlayerResponse(true)
-		u.pushMessage(false, roomID, conn, res)
-
-		u.GameRooms[roomID].VoteBallot[playerID] = 0
-		issuerID := u.Rooms[roomID][conn].ID
-		voteKickBroadcast := events.NewVoteKickPlayerBroadcast(playerID, u.GameRooms[roomID].PlayerMap[issuerID].Name)
-		u.pushMessage(true, roomID, conn, voteKickBroadcast)
-		return
+		// the actor might have stopped right after we got hold of it, in
+		// which case the room has to be claimed again
+		if actor.send(command) {
+			return
+		}
 	}
+}
 
-	_, ok := u.Rooms[roomID]
-	res := events.NewLeaveRoomResponse(true)
-	u.pushMessage(false, roomID, conn, res)
+// ownRoom returns the actor running the room on this node, starting one if
+// the room is not owned by any other node. It returns nil when another node
+// owns the room
+func (u *gameUsecase) ownRoom(roomID string) (*roomActor, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
 
-	if ok {
-		broadcast := events.NewLeaveRoomBroadcast(playerID)
-		u.pushMessage(true, roomID, conn, broadcast)
+	if actor, ok := u.Rooms[roomID]; ok {
+		return actor, nil
 	}
 
-	gameRoom := u.GameRooms[roomID]
-
-	if gameRoom == nil {
-		return
+	owner, err := u.broker.Claim(roomID, u.nodeID)
+	if err != nil {
+		return nil, err
 	}
 
-	// appoint new host if necessary
-	if gameRoom.HostID == playerID {
-		newHostID := gameRoom.NextHost()
-		changeHostBroadcast := events.NewChangeHostBroadcast(newHostID)
-		u.pushMessage(true, roomID, conn, changeHostBroadcast)
+	if owner != u.nodeID {
+		return nil, nil
 	}
 
-	// choose next player if necessary
-	if gameRoom.IsStarted && gameRoom.TurnID == playerID {
-		playerIndex := gameRoom.GetPlayerIndex(playerID)
-		nextTurnId := gameRoom.NextPlayer(playerIndex)
-		newPlayerBroadcast := events.NewPlayCardBroadcast(gameModel.Card{}, gameRoom.Count, gameRoom.IsClockwise, nextTurnId)
-		u.pushMessage(true, roomID, conn, newPlayerBroadcast)
+	// the saved room is loaded before anything can reach the actor, a room
+	// that cannot be loaded is left to whoever claims it next
+	room, err := u.roomRepo.Get(roomID)
+	if err != nil && err != gameModel.ErrRoomNotFound {
+		u.broker.Release(roomID, u.nodeID)
+		return nil, err
 	}
-}
-
-func (u *gameUsecase) voteKickPlayer(conn *websocket.Conn, roomID string, gameRequest events.GameRequest) {
-	log.Printf("Client is voting on room %v", roomID)
-	gameRoom := u.GameRooms[roomID]
-	// playerID := u.Rooms[roomID][conn].ID
 
-	_, ok := gameRoom.VoteBallot[gameRequest.PlayerID]
-	if !ok {
-		return
+	actor := newRoomActor(u, roomID)
+	if room != nil {
+		log.Printf("restoring room %v", roomID)
+		u.restoreLog(room)
+		actor.send(restoreRoomCommand{room: room})
 	}
 
-	if gameRequest.IsAdd {
-		gameRoom.VoteBallot[gameRequest.PlayerID]++
+	unsubscribe, err := u.broker.Subscribe(commandTopic(roomID), actor.receive)
+	if err != nil {
+		u.broker.Release(roomID, u.nodeID)
+		return nil, err
 	}
-	log.Printf("current tally %v", gameRoom.VoteBallot[gameRequest.PlayerID])
+	actor.unsubscribe = unsubscribe
 
-	if gameRequest.IsAdd && gameRoom.VoteBallot[gameRequest.PlayerID] > len(gameRoom.Players)/2 {
-		log.Printf("vote kick success, removing player")
-		delete(gameRoom.VoteBallot, gameRequest.PlayerID)
+	u.Rooms[roomID] = actor
+	go actor.run()
 
-		var targetConn *websocket.Conn
-		connRoom := u.Rooms[roomID]
+	return actor, nil
+}
 
-		for key, val := range connRoom {
-			if val.ID == gameRequest.PlayerID {
-				targetConn = key
-				break
-			}
-		}
+// restoreLog brings back the log of the game a restored room was playing
+func (u *gameUsecase) restoreLog(room *gameModel.Room) {
+	if !room.IsStarted {
+		return
+	}
 
-		if targetConn == nil {
-			return
-		}
+	gameLog, err := u.replayRepo.Running(room.RoomID)
+	if err == gameModel.ErrReplayNotFound {
+		return
+	}
+	if err != nil {
+		log.Printf("failed to load the game log of room %v: %v", room.RoomID, err)
+		return
+	}
 
-		evictionNotice := events.NewLeaveRoomResponse(true)
-		u.pushMessage(false, roomID, targetConn, evictionNotice)
+	room.GameLog = gameLog
+}
 
-		broadcast := events.NewLeaveRoomBroadcast(gameRequest.PlayerID)
-		u.pushMessage(true, roomID, conn, broadcast)
+// keepClaims renews the ownership of every room running on this node
+func (u *gameUsecase) keepClaims() {
+	ticker := time.NewTicker(claimInterval)
+	defer ticker.Stop()
 
-		// appoint new host if necessary
-		if gameRoom.HostID == gameRequest.PlayerID {
-			newHostID := gameRoom.NextHost()
-			changeHostBroadcast := events.NewChangeHostBroadcast(newHostID)
-			u.pushMessage(true, roomID, conn, changeHostBroadcast)
+	for range ticker.C {
+		u.mu.Lock()
+		roomIDs := []string{}
+		for roomID := range u.Rooms {
+			roomIDs = append(roomIDs, roomID)
 		}
-
-		// choose next player if necessary
-		if gameRoom.IsStarted && gameRoom.TurnID == gameRequest.PlayerID {
-			playerIndex := gameRoom.GetPlayerIndex(gameRequest.PlayerID)
-			nextTurnIdx := gameRoom.NextPlayer(playerIndex)
-			nextPlayerBroadcast := events.NewPlayCardBroadcast(gameModel.Card{}, gameRoom.Count, gameRoom.IsClockwise, nextTurnIdx)
-			u.pushMessage(true, roomID, conn, nextPlayerBroadcast)
+		u.mu.Unlock()
+
+		for _, roomID := range roomIDs {
+			owner, err := u.broker.Claim(roomID, u.nodeID)
+			if err != nil {
+				log.Printf("failed to renew claim on room %v: %v", roomID, err)
+			} else if owner != u.nodeID {
+				log.Printf("room %v has been claimed by node %v", roomID, owner)
+				u.mu.Lock()
+				actor := u.Rooms[roomID]
+				u.mu.Unlock()
+				if actor != nil {
+					actor.send(releaseRoomCommand{})
+				}
+			}
 		}
 	}
 }
 
-func (u *gameUsecase) startGame(conn *websocket.Conn, roomID string) {
-	log.Printf("Client trying to start game on room %v", roomID)
-	gameRoom := u.GameRooms[roomID]
-	playerID := u.Rooms[roomID][conn].ID
-
-	if playerID != gameRoom.HostID {
-		res := events.NewStartGameResponse(false)
-		u.pushMessage(false, roomID, conn, res)
+func (u *gameUsecase) forward(roomID string, command remoteCommand) {
+	data, err := json.Marshal(command)
+	if err != nil {
+		log.Printf("failed to encode command for room %v: %v", roomID, err)
 		return
 	}
 
-	if len(gameRoom.Players) < 2 {
-		res := events.NewStartGameResponse(false)
-		u.pushMessage(false, roomID, conn, res)
-		return
+	if err := u.broker.Publish(commandTopic(roomID), data); err != nil {
+		log.Printf("failed to forward command to room %v: %v", roomID, err)
 	}
+}
 
-	starterID := gameRoom.StartGame()
+// isFull tells whether this node runs more rooms than it is allowed to, the
+// room asking is already counted
+func (u *gameUsecase) isFull() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
 
-	u.dealCard(roomID)
+	return len(u.Rooms) > int(configs.Constant.Capacity)
+}
 
-	notifContent := "game started, " + gameRoom.PlayerMap[starterID].Name + "'s turn"
-	notification := events.NewNotificationBroadcast(notifContent)
-	res := events.NewStartGameBroadcast(starterID)
+func (u *gameUsecase) saveRoom(room *gameModel.Room) {
+	if err := u.roomRepo.Save(room); err != nil {
+		log.Printf("failed to save room %v: %v", room.RoomID, err)
+	}
+}
 
-	u.pushMessage(true, roomID, conn, res)
-	u.pushMessage(true, roomID, conn, notification)
+func (u *gameUsecase) saveReplay(gameLog *gameModel.GameLog) {
+	if err := u.replayRepo.Save(gameLog); err != nil {
+		log.Printf("failed to save replay %v of room %v: %v", gameLog.GameID, gameLog.RoomID, err)
+	}
 }
 
-func (u *gameUsecase) playCard(conn *websocket.Conn, roomID string, gameRequest events.GameRequest) {
-	gameRoom := u.GameRooms[roomID]
-	playerID := u.Rooms[roomID][conn].ID
-	if !gameRoom.IsStarted {
-		log.Printf("game is not started")
-		res := events.NewPlayCardResponse(false, nil, 3, "Game is not started")
-		u.pushMessage(false, roomID, conn, res)
-		return
+func (u *gameUsecase) beginLog(gameLog *gameModel.GameLog) {
+	if err := u.replayRepo.Begin(gameLog); err != nil {
+		log.Printf("failed to begin log of game %v in room %v: %v", gameLog.GameID, gameLog.RoomID, err)
 	}
+}
 
-	if gameRoom.TurnID != playerID {
-		log.Printf("its not your turn yet")
-		res := events.NewPlayCardResponse(false, nil, 3, "Please wait for your turn")
-		u.pushMessage(false, roomID, conn, res)
+func (u *gameUsecase) appendLog(roomID string, entry gameModel.LogEntry) {
+	if err := u.replayRepo.Append(roomID, entry); err != nil {
+		log.Printf("failed to append %v to the game log of room %v: %v", entry.Type, roomID, err)
+	}
+}
+
+// rateGame updates the global rating of the accounts that played a game,
+// it runs in the background so that rooms never wait on the account store
+func (u *gameUsecase) rateGame(roomID string, ranking []string) {
+	if u.accounts == nil || len(ranking) < 2 {
 		return
 	}
 
-	playerIndex := gameRoom.GetPlayerIndex(playerID)
+	go func() {
+		if err := u.accounts.RecordGame(ranking); err != nil {
+			log.Printf("failed to rate game of room %v: %v", roomID, err)
+		}
+	}()
+}
 
-	player := gameRoom.PlayerMap[playerID]
+// removeRoom stops running a room on this node, the saved room is deleted
+// only when the room is closed for good
+func (u *gameUsecase) removeRoom(roomID string, actor *roomActor, isClosed bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
 
-	if !player.IsAlive {
-		log.Printf("this player is dead")
-		res := events.NewPlayCardResponse(false, nil, 3, "You are already dead")
-		u.pushMessage(false, roomID, conn, res)
+	if u.Rooms[roomID] != actor {
 		return
 	}
 
-	playedCard := player.Hand[gameRequest.HandIndex]
-	log.Printf("%v is playing: %v", player.Name, playedCard)
-
-	var res events.PlayCardResponse
+	delete(u.Rooms, roomID)
+	actor.unsubscribe()
 
-	success := true
-	if err := gameRoom.PlayCard(playerID, gameRequest.HandIndex, gameRequest.IsAdd, gameRequest.PlayerID); err != nil {
-		success = false
-
-		if !gameRequest.IsDiscard {
-			player.InsertHand(playedCard, gameRequest.HandIndex)
+	if isClosed {
+		log.Printf("delete room %v", roomID)
+		if err := u.roomRepo.Delete(roomID); err != nil {
+			log.Printf("failed to delete room %v: %v", roomID, err)
+		}
+		if err := u.replayRepo.Discard(roomID); err != nil {
+			log.Printf("failed to discard the game log of room %v: %v", roomID, err)
 		}
 	}
 
-	if len(player.Hand) == 0 {
-		player.IsAlive = false
-		deadBroadcast := events.NewDeadPlayerBroadcast(player.PlayerID)
-		u.pushMessage(true, roomID, conn, deadBroadcast)
+	if err := u.broker.Release(roomID, u.nodeID); err != nil {
+		log.Printf("failed to release room %v: %v", roomID, err)
 	}
+}
 
-	if winner := gameRoom.GetWinner(); winner != nil && winner.PlayerID != "" {
-		gameRoom.EndGame(winner.PlayerID)
-		endBroadcast := events.NewEndGameBroadcast(winner)
-		u.pushMessage(true, roomID, conn, endBroadcast)
+func (u *gameUsecase) publish(roomID string, message roomMessage) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("failed to encode message for room %v: %v", roomID, err)
+		return
 	}
 
-	message := ""
-	status := 0
-	if !success && !gameRequest.IsDiscard {
-		status = 1
-		res = events.NewPlayCardResponse(false, player.Hand, status, "Try discarding hand")
-		res.HandIndex = gameRequest.HandIndex
-		u.pushMessage(false, roomID, conn, res)
-		return
+	if err := u.broker.Publish(messageTopic(roomID), data); err != nil {
+		log.Printf("failed to publish message to room %v: %v", roomID, err)
 	}
+}
 
-	if !success && gameRequest.IsDiscard {
-		message = "Hand discarded"
+// deliver hands messages published by a room actor to the connections held
+// by this node
+func (u *gameUsecase) deliver(data []byte) {
+	var message roomMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		log.Printf("failed to decode room message: %v", err)
+		return
 	}
-	res = events.NewPlayCardResponse(success, player.Hand, status, message)
-	u.pushMessage(false, roomID, conn, res)
 
-	var nextPlayerId string
-	if gameRoom.IsStarted {
-		if gameRoom.TurnID == playerID {
-			nextPlayerId = gameRoom.NextPlayer(playerIndex)
-		} else {
-			nextPlayerId = gameRoom.TurnID
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for _, connID := range message.ConnIDs {
+		c, ok := u.conns[connID]
+		if !ok {
+			continue
 		}
-	}
 
-	if !success {
-		playedCard = gameModel.Card{}
+		if message.Message != nil {
+			enqueue(c, message.Message)
+			if message.RequestID != "" {
+				c.Replies.add(message.RequestID, message.Message)
+			}
+		}
+
+		if message.Close {
+			delete(u.conns, connID)
+			close(c.Queue)
+		}
 	}
-	broadcast := events.NewPlayCardBroadcast(playedCard, gameRoom.Count, gameRoom.IsClockwise, nextPlayerId)
-	u.pushMessage(true, roomID, conn, broadcast)
 }
 
-func (u *gameUsecase) broadcastChat(conn *websocket.Conn, roomID string, gameRequest events.GameRequest) {
-	log.Printf("Client is sending chat on room %v", roomID)
+func (u *gameUsecase) registerConn(c *connection) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
 
-	room, ok := u.Rooms[roomID]
-	if ok {
-		playerID := room[conn].ID
-		playerName := u.GameRooms[roomID].PlayerMap[playerID].Name
+	subscription, ok := u.subscriptions[c.RoomID]
+	if !ok {
+		unsubscribe, err := u.broker.Subscribe(messageTopic(c.RoomID), u.deliver)
+		if err != nil {
+			return err
+		}
 
-		log.Printf("player %s send chat", playerName)
-		broadcast := events.NewMessageBroadcast(gameRequest.Message, playerName)
-		u.pushMessage(true, roomID, conn, broadcast)
+		subscription = &roomSubscription{unsubscribe: unsubscribe}
+		u.subscriptions[c.RoomID] = subscription
 	}
-}
 
-func (u *gameUsecase) createConnectionRoom(roomID string, conn *websocket.Conn) {
-	u.Rooms[roomID] = make(map[*websocket.Conn]*connection)
-}
+	subscription.count++
+	u.conns[c.ID] = c
 
-func (u *gameUsecase) createGameRoom(roomID string, hostID string) {
-	gameRoom := gameModel.NewRoom(roomID, hostID, 4)
-	u.GameRooms[roomID] = gameRoom
+	return nil
 }
 
-func (u *gameUsecase) registerPlayer(roomID string, conn *websocket.Conn, player *gameModel.Player) {
-	u.Rooms[roomID][conn] = NewConnection(player.PlayerID)
-	u.GameRooms[roomID].AddPlayer(player)
-	go u.writePump(conn, roomID)
+func (u *gameUsecase) unregisterConn(c *connection) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	// the room actor might have closed the connection already
+	if _, ok := u.conns[c.ID]; ok {
+		delete(u.conns, c.ID)
+		close(c.Queue)
+	}
+
+	subscription := u.subscriptions[c.RoomID]
+	subscription.count--
+	if subscription.count == 0 {
+		delete(u.subscriptions, c.RoomID)
+		go subscription.unsubscribe()
+	}
 }
 
-func (u *gameUsecase) unregisterPlayer(roomID string, conn *websocket.Conn, playerID string) {
-	playerIndex := -1
-	for i, p := range u.GameRooms[roomID].Players {
-		if p.PlayerID == playerID {
-			playerIndex = i
-			break
+// isRetry tells whether the connection has sent the request already, the
+// answers it got are sent again rather than running the request twice
+func (u *gameUsecase) isRetry(c *connection, requestID string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	replies, ok := c.Replies.track(requestID)
+	if !ok {
+		return false
+	}
+
+	// the connection might have been closed by its room in the meantime
+	_, isRoomConn := u.conns[c.ID]
+	_, isLobbyConn := u.lobbyConns[c.ID]
+	if isRoomConn || isLobbyConn {
+		for _, message := range replies {
+			enqueue(c, message)
 		}
 	}
 
-	gameRoom := u.GameRooms[roomID]
-	gameRoom.RemovePlayer(playerIndex)
-	delete(u.Rooms[roomID], conn)
+	return true
+}
 
-	// delete empty room
-	if len(u.GameRooms[roomID].Players) == 0 {
-		log.Printf("delete room %v", roomID)
-		delete(u.GameRooms, roomID)
-		delete(u.Rooms, roomID)
+// decodeRequest turns a frame sent by a client into a request
+func decodeRequest(c *connection, frame []byte) (events.GameRequest, error) {
+	data, err := c.Codec.DecodeRequest(frame)
+	if err != nil {
+		return events.GameRequest{}, &events.ProtocolError{Code: events.CodeMalformedRequest, Detail: "Frame could not be decoded"}
 	}
+
+	return events.DecodeRequest(data)
 }
 
-func (u *gameUsecase) writePump(conn *websocket.Conn, roomID string) {
-	c := u.Rooms[roomID][conn]
+// reject tells the client their request could not be decoded
+func (u *gameUsecase) reject(c *connection, err error) {
+	protocolErr, ok := err.(*events.ProtocolError)
+	if !ok {
+		protocolErr = &events.ProtocolError{Code: events.CodeMalformedRequest, Detail: err.Error()}
+	}
 
-	defer func() {
-		conn.Close()
-	}()
+	data, err := json.Marshal(protocolErr.Response())
+	if err != nil {
+		log.Printf("failed to encode error response: %v", err)
+		return
+	}
 
-	for {
-		message := <-c.Queue
-		conn.WriteJSON(message)
+	u.mu.Lock()
+	defer u.mu.Unlock()
 
-		if _, ok := message.(events.LeaveRoomResponse); ok {
-			u.unregisterPlayer(roomID, conn, c.ID)
-			return
-		}
+	// the connection might have been closed by its room in the meantime
+	_, isRoomConn := u.conns[c.ID]
+	_, isLobbyConn := u.lobbyConns[c.ID]
+	if isRoomConn || isLobbyConn {
+		enqueue(c, data)
 	}
 }
 
-func (u *gameUsecase) dealCard(roomID string) {
-	room := u.Rooms[roomID]
-
-	for connection, playerID := range room {
-		player := u.GameRooms[roomID].PlayerMap[playerID.ID]
-		message := events.NewInitialHandResponse(player.Hand)
-		u.pushMessage(false, roomID, connection, message)
+// enqueue hands a message to the write pump of a connection, the message is
+// dropped when the client can not keep up
+func enqueue(c *connection, message json.RawMessage) {
+	select {
+	case c.Queue <- message:
+	default:
+		log.Printf("queue of connection %v is full, dropping message", c.ID)
 	}
 }
 
-func (u *gameUsecase) RunSwitch() {
+// keepAlive limits the size of the frames a client may send and gives up on
+// a connection that stays silent for too long, which makes its read fail
+// and the player go through the same path as a dropped connection
+func keepAlive(conn *websocket.Conn) {
+	conn.SetReadLimit(configs.Socket.MaxMessageSize)
+	extendDeadline(conn)
+	conn.SetPongHandler(func(string) error {
+		extendDeadline(conn)
+		return nil
+	})
+}
+
+func extendDeadline(conn *websocket.Conn) {
+	conn.SetReadDeadline(time.Now().Add(configs.Socket.PongWait))
+}
+
+// writePump sends the queued messages and pings the client in between. A
+// failed write closes the connection so that the read loop notices as well
+func writePump(conn *websocket.Conn, c *connection) {
+	ticker := time.NewTicker(configs.Socket.PingInterval)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
 	for {
-		event := <-u.SwitchQueue
-		conRoom := u.Rooms[event.RoomID]
-		if conRoom == nil {
-			continue
-		}
+		select {
+		case message, ok := <-c.Queue:
+			conn.SetWriteDeadline(time.Now().Add(configs.Socket.WriteWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
 
-		if event.EventType == events.UnicastSocketEvent {
-			pConn := conRoom[event.Conn]
-			if pConn == nil {
+			frame, err := c.Codec.EncodeMessage(message)
+			if err != nil {
+				log.Printf("failed to encode message for connection %v: %v", c.ID, err)
 				continue
 			}
-			pConn.Queue <- event.Message
-		} else {
-			for _, con := range conRoom {
-				con.Queue <- event.Message
+
+			if err := conn.WriteMessage(c.Codec.FrameType(), frame); err != nil {
+				log.Printf("failed to write to connection %v: %v", c.ID, err)
+				return
+			}
+		case <-ticker.C:
+			deadline := time.Now().Add(configs.Socket.WriteWait)
+			if err := conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				log.Printf("failed to ping connection %v: %v", c.ID, err)
+				return
 			}
 		}
 	}
 }
 
-func (u *gameUsecase) pushMessage(broadcast bool, roomID string, conn *websocket.Conn, message interface{}) {
-	if broadcast {
-		event := events.NewBroadcastEvent(roomID, message)
-		u.SwitchQueue <- event
-	} else {
-		event := events.NewUnicastEvent(roomID, conn, message)
-		u.SwitchQueue <- event
-	}
+func messageTopic(roomID string) string {
+	return "cepex:room:" + roomID + ":messages"
+}
+
+func commandTopic(roomID string) string {
+	return "cepex:room:" + roomID + ":commands"
 }
@@ -0,0 +1,308 @@
+package usecases
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+
+	"github.com/aryuuu/cepex-server/models/events"
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+	"github.com/aryuuu/cepex-server/utils/codec"
+	"github.com/aryuuu/cepex-server/utils/common"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	lobbyTopic = "cepex:lobby"
+
+	// reserveSeatEvent is raised by the lobby to have a room hold a seat for
+	// a quick match
+	reserveSeatEvent = "reserve-seat"
+	// maxQuickMatchAttempts is how many rooms a quick match tries before
+	// giving up
+	maxQuickMatchAttempts = 5
+)
+
+// lobbyMessage is published on the lobby topic, either an update for every
+// lobby connection or the answer of a room to a quick match
+type lobbyMessage struct {
+	Update      json.RawMessage  `json:"update,omitempty"`
+	Reservation *seatReservation `json:"reservation,omitempty"`
+}
+
+// seatReservation is how a room answered a lobby connection asking it for a
+// seat, there is no token when the room had none to give
+type seatReservation struct {
+	ConnID string `json:"id_connection"`
+	RoomID string `json:"id_room"`
+	Token  string `json:"token,omitempty"`
+	IsNew  bool   `json:"is_new"`
+}
+
+// quickMatch is a quick match waiting for a room to hold a seat
+type quickMatch struct {
+	requestID  string
+	candidates []string
+	attempts   int
+}
+
+// ListRooms returns the public rooms waiting for their next game, the
+// fullest first
+func (u *gameUsecase) ListRooms() ([]gameModel.RoomSummary, error) {
+	rooms, err := u.roomRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []gameModel.RoomSummary{}
+	for _, room := range rooms {
+		if summary := gameModel.NewRoomSummary(room); summary.IsListed() {
+			result = append(result, summary)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].PlayerCount != result[j].PlayerCount {
+			return result[i].PlayerCount > result[j].PlayerCount
+		}
+		return result[i].RoomID < result[j].RoomID
+	})
+
+	return result, nil
+}
+
+// ConnectLobby sends the room list to the client and keeps it up to date
+// until the client leaves
+func (u *gameUsecase) ConnectLobby(conn *websocket.Conn) {
+	c := NewConnection(uuid.NewString(), "", codec.New(conn.Subprotocol()))
+
+	u.mu.Lock()
+	u.lobbyConns[c.ID] = c
+	u.mu.Unlock()
+	defer u.unregisterLobbyConn(c)
+
+	keepAlive(conn)
+	go writePump(conn, c)
+
+	rooms, err := u.ListRooms()
+	if err != nil {
+		log.Printf("failed to list rooms: %v", err)
+		rooms = []gameModel.RoomSummary{}
+	}
+	u.pushLobby(c, events.NewLobbyRoomsResponse(rooms))
+
+	for {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			log.Printf("lobby connection closed: %v", err)
+			return
+		}
+		extendDeadline(conn)
+
+		lobbyRequest, err := decodeRequest(c, frame)
+		if err == nil && lobbyRequest.EventType != events.QuickMatchEvent {
+			err = &events.ProtocolError{
+				Code:      events.CodeUnknownType,
+				Request:   lobbyRequest.EventType,
+				RequestID: lobbyRequest.RequestID,
+				Detail:    "Only quick-match can be requested from the lobby",
+			}
+		}
+		if err != nil {
+			u.reject(c, err)
+			continue
+		}
+
+		if u.isRetry(c, lobbyRequest.RequestID) {
+			log.Printf("request %v on the lobby is a retry", lobbyRequest.RequestID)
+			continue
+		}
+
+		u.startQuickMatch(c, lobbyRequest.RequestID)
+	}
+}
+
+// startQuickMatch looks for the fullest room anyone can take a seat in,
+// the client is only answered once a room holds a seat for it
+func (u *gameUsecase) startQuickMatch(c *connection, requestID string) {
+	rooms, err := u.ListRooms()
+	if err != nil {
+		log.Printf("failed to list rooms: %v", err)
+		res := events.NewQuickMatchResponse(false, "", false, "", "Failed to find a room")
+		u.pushLobbyReply(c, requestID, res)
+		return
+	}
+
+	u.mu.Lock()
+	u.quickMatches[c.ID] = &quickMatch{
+		requestID:  requestID,
+		candidates: gameModel.QuickMatchCandidates(rooms),
+	}
+	u.mu.Unlock()
+
+	u.tryQuickMatch(c.ID)
+}
+
+// tryQuickMatch asks the next candidate room for a seat, or a new room once
+// there is none left
+func (u *gameUsecase) tryQuickMatch(connID string) {
+	u.mu.Lock()
+	match, ok := u.quickMatches[connID]
+	c := u.lobbyConns[connID]
+	if !ok || c == nil {
+		u.mu.Unlock()
+		return
+	}
+
+	roomID := ""
+	if match.attempts < maxQuickMatchAttempts {
+		if len(match.candidates) > 0 {
+			roomID = match.candidates[0]
+			match.candidates = match.candidates[1:]
+		} else {
+			roomID = common.GenRandomString(5)
+		}
+		match.attempts++
+	}
+	if roomID == "" {
+		delete(u.quickMatches, connID)
+	}
+	u.mu.Unlock()
+
+	if roomID == "" {
+		res := events.NewQuickMatchResponse(false, "", false, "", "Failed to find a room")
+		u.pushLobbyReply(c, match.requestID, res)
+		return
+	}
+
+	u.dispatch(roomID, connID, events.GameRequest{EventType: reserveSeatEvent})
+}
+
+// settleQuickMatch answers the client once a room has held a seat for it,
+// or moves on to the next room
+func (u *gameUsecase) settleQuickMatch(reservation seatReservation) {
+	u.mu.Lock()
+	match, ok := u.quickMatches[reservation.ConnID]
+	c := u.lobbyConns[reservation.ConnID]
+	if ok && reservation.Token != "" {
+		delete(u.quickMatches, reservation.ConnID)
+	}
+	u.mu.Unlock()
+
+	if !ok || c == nil {
+		return
+	}
+
+	if reservation.Token == "" {
+		// the room might be answering from within its actor, which must not
+		// wait for another room to take the request
+		go u.tryQuickMatch(reservation.ConnID)
+		return
+	}
+
+	res := events.NewQuickMatchResponse(true, reservation.RoomID, reservation.IsNew, reservation.Token, "")
+	u.pushLobbyReply(c, match.requestID, res)
+}
+
+// publishReservation hands the answer of a room to the node holding the
+// lobby connection that asked for a seat
+func (u *gameUsecase) publishReservation(reservation seatReservation) {
+	data, err := json.Marshal(lobbyMessage{Reservation: &reservation})
+	if err != nil {
+		log.Printf("failed to encode seat reservation: %v", err)
+		return
+	}
+
+	if err := u.broker.Publish(lobbyTopic, data); err != nil {
+		log.Printf("failed to publish seat reservation: %v", err)
+	}
+}
+
+func (u *gameUsecase) publishLobby(message interface{}) {
+	update, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("failed to encode lobby message: %v", err)
+		return
+	}
+
+	data, err := json.Marshal(lobbyMessage{Update: update})
+	if err != nil {
+		log.Printf("failed to encode lobby message: %v", err)
+		return
+	}
+
+	if err := u.broker.Publish(lobbyTopic, data); err != nil {
+		log.Printf("failed to publish lobby message: %v", err)
+	}
+}
+
+// deliverLobby hands lobby updates to the lobby connections held by this
+// node, and the answers of rooms to the quick matches they are waiting on
+func (u *gameUsecase) deliverLobby(data []byte) {
+	var message lobbyMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		log.Printf("failed to decode lobby message: %v", err)
+		return
+	}
+
+	if message.Reservation != nil {
+		u.settleQuickMatch(*message.Reservation)
+	}
+
+	if message.Update == nil {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for _, c := range u.lobbyConns {
+		enqueue(c, message.Update)
+	}
+}
+
+func (u *gameUsecase) pushLobby(c *connection, message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("failed to encode lobby message: %v", err)
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, ok := u.lobbyConns[c.ID]; ok {
+		enqueue(c, json.RawMessage(data))
+	}
+}
+
+// pushLobbyReply answers a request of a lobby connection, the answer is
+// kept for when the request is sent again
+func (u *gameUsecase) pushLobbyReply(c *connection, requestID string, message interface{}) {
+	data, err := json.Marshal(message)
+	if err == nil {
+		data, err = events.WithRequestID(data, requestID)
+	}
+	if err != nil {
+		log.Printf("failed to encode lobby message: %v", err)
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, ok := u.lobbyConns[c.ID]; ok {
+		enqueue(c, data)
+		c.Replies.add(requestID, data)
+	}
+}
+
+func (u *gameUsecase) unregisterLobbyConn(c *connection) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	delete(u.lobbyConns, c.ID)
+	delete(u.quickMatches, c.ID)
+	close(c.Queue)
+}
@@ -0,0 +1,48 @@
+package usecases
+
+import "encoding/json"
+
+// replyWindowSize is how many of its last requests a connection can retry
+const replyWindowSize = 64
+
+// replyWindow remembers what the last requests of a connection were
+// answered with, so that a request sent again with the same ID gets the
+// same answer instead of being run twice
+type replyWindow struct {
+	requestIDs []string
+	replies    map[string][]json.RawMessage
+}
+
+func newReplyWindow() *replyWindow {
+	return &replyWindow{
+		requestIDs: []string{},
+		replies:    make(map[string][]json.RawMessage),
+	}
+}
+
+// track records a request, it returns whether the request has been seen
+// before along with what it has been answered with so far. A retry of a
+// request still being handled gets nothing, the answers are on their way
+func (w *replyWindow) track(requestID string) ([]json.RawMessage, bool) {
+	if replies, ok := w.replies[requestID]; ok {
+		return replies, true
+	}
+
+	if len(w.requestIDs) == replyWindowSize {
+		delete(w.replies, w.requestIDs[0])
+		w.requestIDs = w.requestIDs[1:]
+	}
+
+	w.requestIDs = append(w.requestIDs, requestID)
+	w.replies[requestID] = []json.RawMessage{}
+
+	return nil, false
+}
+
+// add remembers an answer to a request, requests that have left the window
+// are not remembered any more
+func (w *replyWindow) add(requestID string, message json.RawMessage) {
+	if replies, ok := w.replies[requestID]; ok {
+		w.replies[requestID] = append(replies, message)
+	}
+}
@@ -0,0 +1,67 @@
+package usecases
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestReplyWindow(t *testing.T) {
+	steps := []struct {
+		name      string
+		requestID string
+		// reply is added to the request when set, otherwise the request is
+		// tracked
+		reply   string
+		replies []json.RawMessage
+		isRetry bool
+	}{
+		{name: "first request", requestID: "1"},
+		{name: "retry in flight", requestID: "1", replies: []json.RawMessage{}, isRetry: true},
+		{name: "answer", requestID: "1", reply: `"a"`},
+		{name: "second answer", requestID: "1", reply: `"b"`},
+		{name: "retry answered", requestID: "1", replies: []json.RawMessage{json.RawMessage(`"a"`), json.RawMessage(`"b"`)}, isRetry: true},
+		{name: "answer of unknown request", requestID: "2", reply: `"c"`},
+		{name: "request after unknown answer", requestID: "2"},
+		{name: "retry without answer", requestID: "2", replies: []json.RawMessage{}, isRetry: true},
+	}
+
+	w := newReplyWindow()
+	for _, s := range steps {
+		if s.reply != "" {
+			w.add(s.requestID, json.RawMessage(s.reply))
+			continue
+		}
+
+		replies, isRetry := w.track(s.requestID)
+		if isRetry != s.isRetry || !reflect.DeepEqual(s.replies, replies) {
+			t.Errorf("%v: expected %v %s, got %v %s", s.name, s.isRetry, s.replies, isRetry, replies)
+		}
+	}
+}
+
+func TestReplyWindowEviction(t *testing.T) {
+	w := newReplyWindow()
+	w.track("first")
+	w.add("first", json.RawMessage(`"a"`))
+
+	for i := 1; i < replyWindowSize; i++ {
+		w.track(fmt.Sprint(i))
+	}
+
+	if _, isRetry := w.track("first"); !isRetry {
+		t.Fatalf("the oldest request should still be in a full window")
+	}
+
+	w.track("one too many")
+	w.add("first", json.RawMessage(`"b"`))
+
+	if len(w.requestIDs) != replyWindowSize || len(w.replies) != replyWindowSize {
+		t.Errorf("window should hold %v requests, got %v ids and %v replies", replyWindowSize, len(w.requestIDs), len(w.replies))
+	}
+
+	if replies, isRetry := w.track("first"); isRetry || replies != nil {
+		t.Errorf("evicted request should be run again, got %v %s", isRetry, replies)
+	}
+}
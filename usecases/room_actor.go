@@ -0,0 +1,1632 @@
+package usecases
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"github.com/aryuuu/cepex-server/configs"
+	"github.com/aryuuu/cepex-server/models/events"
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+	"github.com/aryuuu/cepex-server/utils/token"
+	"github.com/google/uuid"
+)
+
+const (
+	// voteKickCooldown is how long a player has to wait before calling
+	// another vote
+	voteKickCooldown = time.Minute
+
+	botMinDelay = 800 * time.Millisecond
+	botMaxDelay = 2500 * time.Millisecond
+	// roundCountdown is the break between two rounds of a match
+	roundCountdown = 10 * time.Second
+)
+
+// roomCommand is anything a room actor knows how to act upon
+type roomCommand interface{}
+
+// clientRequest is where a command made by a client comes from, every such
+// command embeds it
+type clientRequest struct {
+	connID  string
+	request events.GameRequest
+}
+
+func (r clientRequest) origin() clientRequest {
+	return r
+}
+
+type fromClient interface {
+	origin() clientRequest
+}
+
+type restoreRoomCommand struct {
+	room *gameModel.Room
+}
+
+// releaseRoomCommand stops running the room on this node once another node
+// has claimed it
+type releaseRoomCommand struct{}
+
+type createRoomCommand struct {
+	clientRequest
+	payload *events.CreateRoomPayload
+}
+
+type joinRoomCommand struct {
+	clientRequest
+	payload *events.JoinRoomPayload
+}
+
+type leaveRoomCommand struct {
+	clientRequest
+}
+
+type disconnectCommand struct {
+	clientRequest
+}
+
+type resumeSessionCommand struct {
+	clientRequest
+	payload *events.ResumeSessionPayload
+}
+
+type graceExpiredCommand struct {
+	playerID string
+	timer    *time.Timer
+}
+
+type turnTimeoutCommand struct {
+	playerID string
+	timer    *time.Timer
+}
+
+type kickPlayerCommand struct {
+	clientRequest
+	payload *events.KickPlayerPayload
+}
+
+type startVoteKickCommand struct {
+	clientRequest
+	payload *events.TargetPayload
+}
+
+type banPlayerCommand struct {
+	clientRequest
+	payload *events.TargetPayload
+}
+
+type transferHostCommand struct {
+	clientRequest
+	payload *events.TargetPayload
+}
+
+type lockRoomCommand struct {
+	clientRequest
+	payload *events.LockRoomPayload
+}
+
+type voteKickExpiredCommand struct {
+	targetID string
+	timer    *time.Timer
+}
+
+type voteKickPlayerCommand struct {
+	clientRequest
+	payload *events.CastVotePayload
+}
+
+type startGameCommand struct {
+	clientRequest
+}
+
+type playCardCommand struct {
+	clientRequest
+	payload *events.PlayCardPayload
+}
+
+type promoteSpectatorCommand struct {
+	clientRequest
+	payload *events.TargetPayload
+}
+
+type updateRoomSettingsCommand struct {
+	clientRequest
+	payload *events.UpdateRoomSettingsPayload
+}
+
+type addBotCommand struct {
+	clientRequest
+	payload *events.AddBotPayload
+}
+
+type removeBotCommand struct {
+	clientRequest
+	payload *events.TargetPayload
+}
+
+type botMoveCommand struct {
+	playerID string
+	timer    *time.Timer
+}
+
+type nextRoundCommand struct {
+	timer *time.Timer
+}
+
+type getLeaderboardCommand struct {
+	clientRequest
+}
+
+// getRoomStateCommand asks for a snapshot of the room, gap is set when the
+// member reported missing broadcasts
+type getRoomStateCommand struct {
+	clientRequest
+	gap *events.SequenceGapPayload
+}
+
+// reserveSeatCommand asks the room to hold a seat for the lobby connection
+// of a quick match, or the room itself when it is yet to be created
+type reserveSeatCommand struct {
+	clientRequest
+}
+
+type creationExpiredCommand struct {
+	timer *time.Timer
+}
+
+type chatCommand struct {
+	clientRequest
+	payload *events.ChatPayload
+}
+
+// roomActor runs a single room, its goroutine is the only code allowed to
+// touch the room and its members
+type roomActor struct {
+	usecase     *gameUsecase
+	roomID      string
+	room        *gameModel.Room
+	members     map[string]string
+	graceTimers map[string]*time.Timer
+	voteTimers  map[string]*time.Timer
+	voteStarts  map[string]time.Time
+	turnTimer   *time.Timer
+	botTimer    *time.Timer
+	roundTimer  *time.Timer
+	strategies  map[string]gameModel.Strategy
+	summary     *gameModel.RoomSummary
+	// creation holds a room that is yet to be created for whoever quick
+	// match sent to create it
+	creation *creationReservation
+	// origin is the client request being handled, whatever is sent back to
+	// its connection answers it
+	origin      clientRequest
+	commands    chan roomCommand
+	done        chan struct{}
+	unsubscribe func()
+}
+
+type creationReservation struct {
+	token string
+	timer *time.Timer
+}
+
+func newRoomActor(u *gameUsecase, roomID string) *roomActor {
+	return &roomActor{
+		usecase:     u,
+		roomID:      roomID,
+		members:     make(map[string]string),
+		graceTimers: make(map[string]*time.Timer),
+		voteTimers:  make(map[string]*time.Timer),
+		voteStarts:  make(map[string]time.Time),
+		strategies:  make(map[string]gameModel.Strategy),
+		commands:    make(chan roomCommand, 256),
+		done:        make(chan struct{}),
+	}
+}
+
+// newRoomCommand turns a client request into the command the room actor
+// should act upon, unknown requests yield nil
+func newRoomCommand(connID string, gameRequest events.GameRequest) roomCommand {
+	origin := clientRequest{connID: connID, request: gameRequest}
+
+	switch payload := gameRequest.Payload.(type) {
+	case *events.CreateRoomPayload:
+		return createRoomCommand{origin, payload}
+	case *events.JoinRoomPayload:
+		return joinRoomCommand{origin, payload}
+	case *events.ResumeSessionPayload:
+		return resumeSessionCommand{origin, payload}
+	case *events.KickPlayerPayload:
+		return kickPlayerCommand{origin, payload}
+	case *events.LockRoomPayload:
+		return lockRoomCommand{origin, payload}
+	case *events.CastVotePayload:
+		return voteKickPlayerCommand{origin, payload}
+	case *events.PlayCardPayload:
+		return playCardCommand{origin, payload}
+	case *events.UpdateRoomSettingsPayload:
+		return updateRoomSettingsCommand{origin, payload}
+	case *events.AddBotPayload:
+		return addBotCommand{origin, payload}
+	case *events.SequenceGapPayload:
+		return getRoomStateCommand{origin, payload}
+	case *events.ChatPayload:
+		return chatCommand{origin, payload}
+	case *events.TargetPayload:
+		return newTargetCommand(origin, payload)
+	}
+
+	switch gameRequest.EventType {
+	case events.LeaveRoomEvent:
+		return leaveRoomCommand{origin}
+	case disconnectEvent:
+		return disconnectCommand{origin}
+	case events.StartGameEvent:
+		return startGameCommand{origin}
+	case events.GetLeaderboardEvent:
+		return getLeaderboardCommand{origin}
+	case events.GetRoomStateEvent:
+		return getRoomStateCommand{origin, nil}
+	case reserveSeatEvent:
+		return reserveSeatCommand{origin}
+	default:
+		return nil
+	}
+}
+
+// newTargetCommand tells apart the requests aimed at a single member
+func newTargetCommand(origin clientRequest, payload *events.TargetPayload) roomCommand {
+	switch origin.request.EventType {
+	case events.VoteKickEvent:
+		return startVoteKickCommand{origin, payload}
+	case events.BanPlayerEvent:
+		return banPlayerCommand{origin, payload}
+	case events.TransferHostEvent:
+		return transferHostCommand{origin, payload}
+	case events.PromoteSpectatorEvent:
+		return promoteSpectatorCommand{origin, payload}
+	case events.RemoveBotEvent:
+		return removeBotCommand{origin, payload}
+	default:
+		return nil
+	}
+}
+
+// send queues a command for the actor, it returns false when the actor has
+// already stopped
+func (a *roomActor) send(command roomCommand) bool {
+	select {
+	case a.commands <- command:
+		return true
+	case <-a.done:
+		return false
+	}
+}
+
+// receive takes in commands forwarded by other nodes
+func (a *roomActor) receive(data []byte) {
+	var command remoteCommand
+	if err := json.Unmarshal(data, &command); err != nil {
+		log.Printf("room %v failed to decode forwarded command: %v", a.roomID, err)
+		return
+	}
+
+	if c := newRoomCommand(command.ConnID, command.Request); c != nil {
+		a.send(c)
+	}
+}
+
+func (a *roomActor) run() {
+	defer close(a.done)
+
+	for command := range a.commands {
+		if _, ok := command.(releaseRoomCommand); ok {
+			a.releaseRoom()
+			return
+		}
+
+		a.origin = clientRequest{}
+		if c, ok := command.(fromClient); ok {
+			a.origin = c.origin()
+		}
+
+		if a.room == nil {
+			a.handleMissingRoom(command)
+		} else {
+			a.handle(command)
+		}
+
+		if a.room == nil && a.creation != nil {
+			continue
+		}
+
+		if a.room == nil || !a.room.HasHumans() {
+			a.closeRoom()
+			return
+		}
+
+		a.finishGameLog()
+		a.usecase.saveRoom(a.room)
+		a.announce()
+	}
+}
+
+// closeRoom sends away whoever is still watching the room and stops running
+// it for good
+func (a *roomActor) closeRoom() {
+	for connID := range a.members {
+		evictionNotice := events.NewLeaveRoomResponse(true)
+		a.pushMessage(connID, evictionNotice)
+		a.unregisterConn(connID)
+	}
+
+	if a.summary != nil && a.summary.IsListed() {
+		a.usecase.publishLobby(events.NewLobbyUpdateBroadcast(*a.summary, false))
+	}
+
+	a.usecase.removeRoom(a.roomID, a, true)
+}
+
+// releaseRoom lets go of a room another node now runs, the saved room is
+// left to the new owner and members resume their session over there
+func (a *roomActor) releaseRoom() {
+	log.Printf("room %v has been claimed by another node, letting go", a.roomID)
+
+	for connID := range a.members {
+		a.unregisterConn(connID)
+	}
+
+	a.usecase.removeRoom(a.roomID, a, false)
+}
+
+// announce tells the lobby about the room whenever what it shows changes
+func (a *roomActor) announce() {
+	summary := gameModel.NewRoomSummary(a.room)
+	if a.summary != nil && reflect.DeepEqual(*a.summary, summary) {
+		return
+	}
+
+	wasListed := a.summary != nil && a.summary.IsListed()
+	a.summary = &summary
+	if !wasListed && !summary.IsListed() {
+		return
+	}
+
+	a.usecase.publishLobby(events.NewLobbyUpdateBroadcast(summary, summary.IsListed()))
+}
+
+// permissions tells which role a client request needs, requests made by the
+// server itself or by people yet to join need none
+func permissions(command roomCommand) gameModel.Role {
+	switch c := command.(type) {
+	case kickPlayerCommand:
+		// kicking nobody is how one leaves the room
+		if c.payload.PlayerID == "" {
+			return gameModel.SpectatorRole
+		}
+		return gameModel.HostRole
+	case banPlayerCommand:
+		return gameModel.HostRole
+	case transferHostCommand:
+		return gameModel.HostRole
+	case lockRoomCommand:
+		return gameModel.HostRole
+	case startGameCommand:
+		return gameModel.HostRole
+	case promoteSpectatorCommand:
+		return gameModel.HostRole
+	case updateRoomSettingsCommand:
+		return gameModel.HostRole
+	case addBotCommand:
+		return gameModel.HostRole
+	case removeBotCommand:
+		return gameModel.HostRole
+	case startVoteKickCommand:
+		return gameModel.PlayerRole
+	case voteKickPlayerCommand:
+		return gameModel.PlayerRole
+	case playCardCommand:
+		return gameModel.PlayerRole
+	case getLeaderboardCommand:
+		return gameModel.SpectatorRole
+	case getRoomStateCommand:
+		return gameModel.SpectatorRole
+	case chatCommand:
+		return gameModel.SpectatorRole
+	default:
+		return gameModel.GuestRole
+	}
+}
+
+// authorize checks the role of whoever issued the command before it is
+// handled, the issuer is told when they are not allowed to
+func (a *roomActor) authorize(command roomCommand) bool {
+	role := permissions(command)
+	if role == gameModel.GuestRole {
+		return true
+	}
+
+	origin := command.(fromClient).origin()
+	if err := a.room.Authorize(a.members[origin.connID], role); err != nil {
+		res := events.NewErrorResponse(events.CodeUnauthorized, origin.request.EventType, origin.request.RequestID, err.Error())
+		a.pushMessage(origin.connID, res)
+		return false
+	}
+
+	return true
+}
+
+func (a *roomActor) handle(command roomCommand) {
+	if !a.authorize(command) {
+		return
+	}
+
+	switch c := command.(type) {
+	case createRoomCommand:
+		a.createRoom(c.connID, c.request.Identity, c.payload)
+	case joinRoomCommand:
+		a.joinRoom(c.connID, c.request.Identity, c.payload)
+	case leaveRoomCommand:
+		a.leaveRoom(c.connID)
+	case disconnectCommand:
+		a.disconnect(c.connID)
+	case resumeSessionCommand:
+		a.resumeSession(c.connID, c.request.Identity, c.payload)
+	case graceExpiredCommand:
+		a.expireGrace(c.playerID, c.timer)
+	case turnTimeoutCommand:
+		a.timeoutTurn(c.playerID, c.timer)
+	case kickPlayerCommand:
+		a.kickPlayer(c.connID, c.payload)
+	case startVoteKickCommand:
+		a.startVoteKick(c.connID, c.payload)
+	case voteKickPlayerCommand:
+		a.voteKickPlayer(c.connID, c.payload)
+	case voteKickExpiredCommand:
+		a.expireVoteKick(c.targetID, c.timer)
+	case banPlayerCommand:
+		a.banPlayer(c.connID, c.payload)
+	case transferHostCommand:
+		a.transferHost(c.connID, c.payload)
+	case lockRoomCommand:
+		a.lockRoom(c.connID, c.payload)
+	case startGameCommand:
+		a.startGame(c.connID)
+	case playCardCommand:
+		a.playCard(c.connID, c.payload)
+	case promoteSpectatorCommand:
+		a.promoteSpectator(c.connID, c.payload)
+	case updateRoomSettingsCommand:
+		a.updateRoomSettings(c.connID, c.payload)
+	case addBotCommand:
+		a.addBot(c.connID, c.payload)
+	case removeBotCommand:
+		a.removeBot(c.connID, c.payload)
+	case botMoveCommand:
+		a.moveBot(c.playerID, c.timer)
+	case nextRoundCommand:
+		a.startNextRound(c.timer)
+	case getLeaderboardCommand:
+		a.pushLeaderboard(c.connID)
+	case getRoomStateCommand:
+		a.pushRoomState(c.connID, c.gap)
+	case chatCommand:
+		a.broadcastChat(c.connID, c.payload)
+	case reserveSeatCommand:
+		a.reserveSeat(c.connID)
+	default:
+		log.Printf("room %v received unknown command %T", a.roomID, command)
+	}
+}
+
+// handleMissingRoom deals with commands arriving before the room has been
+// created or restored
+func (a *roomActor) handleMissingRoom(command roomCommand) {
+	switch c := command.(type) {
+	case restoreRoomCommand:
+		a.restoreRoom(c.room)
+	case createRoomCommand:
+		a.createRoom(c.connID, c.request.Identity, c.payload)
+	case joinRoomCommand:
+		log.Printf("room %v does not exist", a.roomID)
+		res := events.NewJoinRoomResponse(false, &gameModel.Room{}, "Room does not exist")
+		a.pushMessage(c.connID, res)
+	case resumeSessionCommand:
+		res := events.NewResumeSessionResponse(false, &gameModel.Room{}, nil, "Room does not exist")
+		a.pushMessage(c.connID, res)
+	case reserveSeatCommand:
+		a.reserveCreation(c.connID)
+	case creationExpiredCommand:
+		a.expireCreation(c.timer)
+	}
+}
+
+// reserveSeat holds a seat for a quick match
+func (a *roomActor) reserveSeat(connID string) {
+	reservation := seatReservation{ConnID: connID, RoomID: a.roomID}
+	token, err := a.room.ReserveSeat()
+	if err != nil {
+		log.Printf("room %v has no seat for a quick match: %v", a.roomID, err)
+	} else {
+		reservation.Token = token
+	}
+
+	a.usecase.publishReservation(reservation)
+}
+
+// reserveCreation holds the room for a quick match that is to create it,
+// no one else can create it until the reservation window is over
+func (a *roomActor) reserveCreation(connID string) {
+	reservation := seatReservation{ConnID: connID, RoomID: a.roomID, IsNew: true}
+	if a.creation == nil {
+		var timer *time.Timer
+		timer = time.AfterFunc(gameModel.ReservationWindow, func() {
+			a.send(creationExpiredCommand{timer: timer})
+		})
+		a.creation = &creationReservation{token: uuid.NewString(), timer: timer}
+		reservation.Token = a.creation.token
+	}
+
+	a.usecase.publishReservation(reservation)
+}
+
+func (a *roomActor) expireCreation(timer *time.Timer) {
+	if a.creation == nil || a.creation.timer != timer {
+		return
+	}
+
+	log.Printf("nobody came to create room %v", a.roomID)
+	a.creation = nil
+}
+
+// restoreRoom picks up a room saved by a previous run, every player gets the
+// usual grace period to resume their session
+func (a *roomActor) restoreRoom(room *gameModel.Room) {
+	a.room = room
+
+	for _, p := range append(room.Players, room.Spectators...) {
+		if p.IsBot {
+			continue
+		}
+		p.IsConnected = false
+		a.startGraceTimer(p.PlayerID)
+	}
+
+	for _, vote := range room.VoteBallot {
+		a.scheduleVoteTimer(vote)
+	}
+
+	a.scheduleTurnTimer()
+}
+
+func (a *roomActor) createRoom(connID string, identity gameModel.Identity, payload *events.CreateRoomPayload) {
+	log.Printf("Client trying to create a new room with ID %v", a.roomID)
+
+	if a.room != nil {
+		message := events.NewCreateRoomResponse(false, &gameModel.Room{RoomID: a.roomID}, "Room already exists")
+		a.pushMessage(connID, message)
+		return
+	}
+
+	if a.creation != nil && payload.Reservation != a.creation.token {
+		message := events.NewCreateRoomResponse(false, &gameModel.Room{RoomID: a.roomID}, "Room is reserved")
+		a.pushMessage(connID, message)
+		return
+	}
+
+	settings := payload.Settings
+	if err := settings.Validate(); err != nil {
+		message := events.NewCreateRoomResponse(false, &gameModel.Room{RoomID: a.roomID}, err.Error())
+		a.pushMessage(connID, message)
+		return
+	}
+
+	if a.usecase.isFull() {
+		message := events.NewCreateRoomResponse(false, &gameModel.Room{RoomID: a.roomID}, "Server is full")
+		a.pushMessage(connID, message)
+		return
+	}
+
+	if a.creation != nil {
+		a.creation.timer.Stop()
+		a.creation = nil
+	}
+
+	player := newMember(identity, payload.ClientName, payload.AvatarURL)
+
+	a.room = gameModel.NewRoom(a.roomID, player.PlayerID, settings.Capacity)
+	a.room.UpdateSettings(settings)
+	a.registerPlayer(connID, player)
+
+	res := events.NewCreateRoomResponse(true, a.room, "")
+	res.SessionToken = a.sessionToken(player.PlayerID)
+	a.pushMessage(connID, res)
+}
+
+func (a *roomActor) joinRoom(connID string, identity gameModel.Identity, payload *events.JoinRoomPayload) {
+	log.Printf("Client trying to join room %v", a.roomID)
+
+	if _, ok := a.members[connID]; ok {
+		res := events.NewJoinRoomResponse(false, &gameModel.Room{}, "You are already in this room")
+		a.pushMessage(connID, res)
+		return
+	}
+
+	if a.getMember(identity.PlayerID) != nil {
+		res := events.NewJoinRoomResponse(false, &gameModel.Room{}, "You are already in this room, resume your session instead")
+		a.pushMessage(connID, res)
+		return
+	}
+
+	if a.room.IsBanned(identity) {
+		res := events.NewJoinRoomResponse(false, &gameModel.Room{}, gameModel.ErrBanned.Error())
+		a.pushMessage(connID, res)
+		return
+	}
+
+	if a.room.IsLocked {
+		res := events.NewJoinRoomResponse(false, &gameModel.Room{}, gameModel.ErrRoomLocked.Error())
+		a.pushMessage(connID, res)
+		return
+	}
+
+	if a.room.IsUsernameExist(payload.ClientName) {
+		log.Printf("username %s already exist", payload.ClientName)
+		res := events.NewJoinRoomResponse(false, &gameModel.Room{}, "username already exist")
+		a.pushMessage(connID, res)
+		return
+	}
+
+	if !a.room.CheckPassword(payload.Password) {
+		res := events.NewJoinRoomResponse(false, &gameModel.Room{}, "Wrong password")
+		a.pushMessage(connID, res)
+		return
+	}
+
+	hasSeat := payload.Reservation != "" && a.room.HoldsReservation(payload.Reservation)
+	if !payload.AsSpectator && !hasSeat && a.room.IsFull() {
+		detail := fmt.Sprintf("Room is full, all %v seats are taken", a.room.Capacity)
+		res := events.NewJoinRoomResponse(false, &gameModel.Room{}, detail)
+		a.pushMessage(connID, res)
+		return
+	}
+
+	a.room.ClaimReservation(payload.Reservation)
+
+	player := newMember(identity, payload.ClientName, payload.AvatarURL)
+	if payload.AsSpectator {
+		a.room.AddSpectator(player)
+		a.members[connID] = player.PlayerID
+	} else {
+		a.registerPlayer(connID, player)
+	}
+
+	res := events.NewJoinRoomResponse(true, a.room, "")
+	res.SessionToken = a.sessionToken(player.PlayerID)
+	a.pushMessage(connID, res)
+
+	broadcast := events.NewJoinRoomBroadcast(player)
+	broadcast.IsSpectator = payload.AsSpectator
+	a.broadcast(broadcast)
+}
+
+func (a *roomActor) leaveRoom(connID string) {
+	log.Printf("Client trying to leave room %v", a.roomID)
+
+	playerID, ok := a.members[connID]
+	if !ok {
+		return
+	}
+
+	a.removePlayer(playerID)
+}
+
+// disconnect keeps the seat of a player whose connection dropped for the
+// grace period, giving them the chance to resume the session
+func (a *roomActor) disconnect(connID string) {
+	playerID, ok := a.members[connID]
+	if !ok {
+		return
+	}
+
+	log.Printf("player %v of room %v disconnected", playerID, a.roomID)
+	a.unregisterConn(connID)
+
+	player := a.getMember(playerID)
+	if player == nil {
+		return
+	}
+	player.IsConnected = false
+	a.startGraceTimer(player.PlayerID)
+
+	broadcast := events.NewPlayerDisconnectedBroadcast(player.PlayerID, int(configs.Session.GracePeriod.Seconds()))
+	a.broadcast(broadcast)
+}
+
+func (a *roomActor) startGraceTimer(playerID string) {
+	if timer, ok := a.graceTimers[playerID]; ok {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(configs.Session.GracePeriod, func() {
+		a.send(graceExpiredCommand{playerID: playerID, timer: timer})
+	})
+	a.graceTimers[playerID] = timer
+}
+
+func (a *roomActor) expireGrace(playerID string, timer *time.Timer) {
+	if a.graceTimers[playerID] != timer {
+		return
+	}
+	delete(a.graceTimers, playerID)
+
+	log.Printf("grace period of player %v in room %v is over", playerID, a.roomID)
+	a.removePlayer(playerID)
+}
+
+// resumeSession rebinds a new connection to the seat the session token was
+// issued for and replays the state the player has missed
+func (a *roomActor) resumeSession(connID string, identity gameModel.Identity, payload *events.ResumeSessionPayload) {
+	fields, err := token.Verify(configs.Session.Secret, payload.SessionToken)
+	if err != nil || len(fields) != 2 || fields[0] != a.roomID || fields[1] != identity.PlayerID {
+		res := events.NewResumeSessionResponse(false, &gameModel.Room{}, nil, "Invalid session token")
+		a.pushMessage(connID, res)
+		return
+	}
+
+	player := a.getMember(fields[1])
+	if player == nil {
+		res := events.NewResumeSessionResponse(false, &gameModel.Room{}, nil, "Session has expired")
+		a.pushMessage(connID, res)
+		return
+	}
+
+	if oldConn := a.getConn(player.PlayerID); oldConn != "" {
+		a.unregisterConn(oldConn)
+	}
+
+	if timer, ok := a.graceTimers[player.PlayerID]; ok {
+		timer.Stop()
+		delete(a.graceTimers, player.PlayerID)
+	}
+
+	player.IsConnected = true
+	a.members[connID] = player.PlayerID
+
+	res := events.NewResumeSessionResponse(true, a.room, player, "")
+	res.SessionToken = a.sessionToken(player.PlayerID)
+	a.pushMessage(connID, res)
+
+	broadcast := events.NewPlayerReconnectedBroadcast(player.PlayerID)
+	a.broadcast(broadcast)
+
+	if a.room.IsStarted && a.room.TurnID == player.PlayerID {
+		a.pushLegalMoves(player.PlayerID)
+	}
+}
+
+// kickPlayer lets the host send someone out of the room right away
+func (a *roomActor) kickPlayer(connID string, payload *events.KickPlayerPayload) {
+	if payload.PlayerID == "" {
+		a.leaveRoom(connID)
+		return
+	}
+
+	a.evict(connID, events.KickPlayerEvent, payload.PlayerID, false)
+}
+
+// banPlayer kicks someone and keeps them from coming back for as long as
+// the room lives
+func (a *roomActor) banPlayer(connID string, payload *events.TargetPayload) {
+	a.evict(connID, events.BanPlayerEvent, payload.PlayerID, true)
+}
+
+func (a *roomActor) evict(connID, action, playerID string, isBanned bool) {
+	player := a.getMember(playerID)
+	if player == nil {
+		res := events.NewHostActionResponse(action, false, gameModel.ErrPlayerNotFound.Error())
+		a.pushMessage(connID, res)
+		return
+	}
+
+	if playerID == a.members[connID] {
+		res := events.NewHostActionResponse(action, false, "You can not remove yourself")
+		a.pushMessage(connID, res)
+		return
+	}
+
+	if isBanned {
+		a.room.Ban(player)
+	}
+
+	res := events.NewHostActionResponse(action, true, "")
+	a.pushMessage(connID, res)
+
+	broadcast := events.NewPlayerKickedBroadcast(playerID, isBanned)
+	a.broadcast(broadcast)
+
+	a.removePlayer(playerID)
+	delete(a.strategies, playerID)
+}
+
+// transferHost lets the host hand the room over to another player
+func (a *roomActor) transferHost(connID string, payload *events.TargetPayload) {
+	if err := a.room.TransferHost(payload.PlayerID); err != nil {
+		res := events.NewHostActionResponse(events.TransferHostEvent, false, err.Error())
+		a.pushMessage(connID, res)
+		return
+	}
+
+	res := events.NewHostActionResponse(events.TransferHostEvent, true, "")
+	a.pushMessage(connID, res)
+
+	broadcast := events.NewChangeHostBroadcast(payload.PlayerID)
+	a.broadcast(broadcast)
+}
+
+// lockRoom lets the host keep newcomers out, members may still come back
+func (a *roomActor) lockRoom(connID string, payload *events.LockRoomPayload) {
+	a.room.IsLocked = payload.IsLocked
+
+	res := events.NewHostActionResponse(events.LockRoomEvent, true, "")
+	a.pushMessage(connID, res)
+
+	broadcast := events.NewLockRoomBroadcast(a.room.IsLocked)
+	a.broadcast(broadcast)
+}
+
+// startVoteKick lets any player call for a vote on kicking someone, as long
+// as they have not called one too recently
+func (a *roomActor) startVoteKick(connID string, payload *events.TargetPayload) {
+	issuerID := a.members[connID]
+	targetID := payload.PlayerID
+
+	if last, ok := a.voteStarts[issuerID]; ok && time.Since(last) < voteKickCooldown {
+		detail := fmt.Sprintf("Please wait %v seconds before calling another vote", int((voteKickCooldown-time.Since(last)).Seconds())+1)
+		res := events.NewVoteKickPlayerResponse(false, detail)
+		a.pushMessage(connID, res)
+		return
+	}
+
+	vote, err := a.room.StartVoteKick(issuerID, targetID)
+	if err != nil {
+		res := events.NewVoteKickPlayerResponse(false, err.Error())
+		a.pushMessage(connID, res)
+		return
+	}
+	a.voteStarts[issuerID] = time.Now()
+	a.scheduleVoteTimer(vote)
+
+	res := events.NewVoteKickPlayerResponse(true, "")
+	a.pushMessage(connID, res)
+
+	voteKickBroadcast := events.NewVoteKickPlayerBroadcast(targetID, a.room.PlayerMap[issuerID].Name)
+	a.broadcast(voteKickBroadcast)
+
+	a.settleVoteKick(targetID, false)
+}
+
+// voteKickPlayer records a vote on kicking someone
+func (a *roomActor) voteKickPlayer(connID string, payload *events.CastVotePayload) {
+	log.Printf("Client is voting on room %v", a.roomID)
+
+	if err := a.room.CastVote(a.members[connID], payload.PlayerID, payload.IsYes); err != nil {
+		res := events.NewCastVoteResponse(false, err.Error())
+		a.pushMessage(connID, res)
+		return
+	}
+
+	res := events.NewCastVoteResponse(true, "")
+	a.pushMessage(connID, res)
+
+	a.settleVoteKick(payload.PlayerID, false)
+}
+
+func (a *roomActor) scheduleVoteTimer(vote *gameModel.VoteKick) {
+	targetID := vote.TargetID
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(vote.ExpiresAt), func() {
+		a.send(voteKickExpiredCommand{targetID: targetID, timer: timer})
+	})
+	a.voteTimers[targetID] = timer
+}
+
+func (a *roomActor) expireVoteKick(targetID string, timer *time.Timer) {
+	if a.voteTimers[targetID] != timer {
+		return
+	}
+
+	a.settleVoteKick(targetID, true)
+}
+
+// settleVoteKick tells everyone where the vote on the target stands, or how
+// it ended once it can no longer go the other way
+func (a *roomActor) settleVoteKick(targetID string, isClosed bool) {
+	if _, ok := a.room.VoteBallot[targetID]; !ok {
+		return
+	}
+
+	tally := a.room.TallyVoteKick(targetID)
+	outcome := tally.Outcome(isClosed)
+	if outcome == "" {
+		broadcast := events.NewVoteKickTallyBroadcast(tally)
+		a.broadcast(broadcast)
+		return
+	}
+
+	log.Printf("vote kick on %v of room %v %v", targetID, a.roomID, outcome)
+	a.closeVoteKick(targetID)
+
+	broadcast := events.NewVoteKickResultBroadcast(outcome, tally)
+	a.broadcast(broadcast)
+
+	if outcome == gameModel.VotePassed {
+		a.removePlayer(targetID)
+	}
+}
+
+func (a *roomActor) closeVoteKick(targetID string) {
+	if timer, ok := a.voteTimers[targetID]; ok {
+		timer.Stop()
+		delete(a.voteTimers, targetID)
+	}
+
+	a.room.CloseVoteKick(targetID)
+}
+
+// removePlayer evicts a player or a spectator from the room, appointing a new
+// host and passing the turn along when needed
+func (a *roomActor) removePlayer(playerID string) {
+	gameRoom := a.room
+	isSeated := gameRoom.GetPlayerIndex(playerID) >= 0
+	if !isSeated && gameRoom.GetSpectator(playerID) == nil {
+		return
+	}
+
+	targetConn := a.getConn(playerID)
+	if targetConn != "" {
+		evictionNotice := events.NewLeaveRoomResponse(true)
+		a.pushMessage(targetConn, evictionNotice)
+	}
+
+	broadcast := events.NewLeaveRoomBroadcast(playerID)
+	a.broadcast(broadcast)
+
+	if isSeated {
+		a.leaveSeat(playerID)
+	} else {
+		gameRoom.RemoveSpectator(playerID)
+	}
+
+	if targetConn != "" {
+		a.unregisterConn(targetConn)
+	}
+
+	if timer, ok := a.graceTimers[playerID]; ok {
+		timer.Stop()
+		delete(a.graceTimers, playerID)
+	}
+
+	// whoever leaves can not be voted out any more, nor vote
+	a.closeVoteKick(playerID)
+	for targetID := range a.room.VoteBallot {
+		a.settleVoteKick(targetID, false)
+	}
+}
+
+func (a *roomActor) leaveSeat(playerID string) {
+	gameRoom := a.room
+
+	isStarted := gameRoom.IsStarted
+	result := gameRoom.Leave(playerID)
+	if isStarted {
+		a.record(gameModel.LeaveAction, playerID, nil)
+	}
+
+	// announce the new host if necessary
+	if result.NewHostID != "" {
+		changeHostBroadcast := events.NewChangeHostBroadcast(result.NewHostID)
+		a.broadcast(changeHostBroadcast)
+	}
+
+	if result.Winner != nil {
+		a.broadcastEndGame(result.Winner)
+		a.scheduleTurnTimer()
+	} else if result.NextPlayerID != "" {
+		nextPlayerBroadcast := events.NewPlayCardBroadcast(gameModel.Card{}, gameRoom.Count, gameRoom.IsClockwise, result.NextPlayerID)
+		a.broadcast(nextPlayerBroadcast)
+		a.scheduleTurnTimer()
+	}
+}
+
+// promoteSpectator lets the host give a spectator a seat between games
+func (a *roomActor) promoteSpectator(connID string, payload *events.TargetPayload) {
+	if err := a.room.PromoteSpectator(payload.PlayerID); err != nil {
+		res := events.NewPromoteSpectatorResponse(false, err.Error())
+		a.pushMessage(connID, res)
+		return
+	}
+
+	res := events.NewPromoteSpectatorResponse(true, "")
+	a.pushMessage(connID, res)
+
+	broadcast := events.NewPromoteSpectatorBroadcast(payload.PlayerID)
+	a.broadcast(broadcast)
+}
+
+func (a *roomActor) startGame(connID string) {
+	log.Printf("Client trying to start game on room %v", a.roomID)
+	gameRoom := a.room
+
+	if len(gameRoom.Players) < 2 || gameRoom.IsStarted {
+		res := events.NewStartGameResponse(false)
+		a.pushMessage(connID, res)
+		return
+	}
+
+	a.beginGame()
+}
+
+// beginGame deals a new game, or the next round when a match is on
+func (a *roomActor) beginGame() {
+	gameRoom := a.room
+
+	if a.roundTimer != nil {
+		a.roundTimer.Stop()
+		a.roundTimer = nil
+	}
+
+	if gameRoom.Settings.IsMatch() {
+		if gameRoom.Match == nil {
+			gameRoom.Match = gameModel.NewMatch()
+		}
+		gameRoom.Match.Round++
+	}
+
+	starterID := gameRoom.StartGame()
+	gameRoom.GameLog = gameModel.NewGameLog(uuid.NewString(), gameRoom)
+	a.usecase.beginLog(gameRoom.GameLog)
+	log.Printf("room %v started game %v with seed %v", a.roomID, gameRoom.GameLog.GameID, gameRoom.Seed)
+
+	a.dealCard()
+
+	notifContent := "game started, " + gameRoom.PlayerMap[starterID].Name + "'s turn"
+	notification := events.NewNotificationBroadcast(notifContent)
+	res := events.NewStartGameBroadcast(starterID)
+	res.GameID = gameRoom.GameLog.GameID
+
+	a.broadcast(res)
+	a.broadcast(notification)
+	a.scheduleTurnTimer()
+}
+
+func (a *roomActor) playCard(connID string, payload *events.PlayCardPayload) {
+	a.playTurn(a.members[connID], payload.Move())
+}
+
+func (a *roomActor) playTurn(playerID string, move gameModel.Move) {
+	gameRoom := a.room
+	connID := a.getConn(playerID)
+	player := gameRoom.PlayerMap[playerID]
+
+	result, err := gameRoom.Play(playerID, move)
+	switch err {
+	case nil:
+	case gameModel.ErrGameNotStarted, gameModel.ErrNotYourTurn, gameModel.ErrPlayerDead, gameModel.ErrCardUnavailable:
+		log.Printf("player %v can not play: %v", playerID, err)
+		res := events.NewPlayCardResponse(false, nil, 3, err.Error())
+		a.pushMessage(connID, res)
+		return
+	default:
+		res := events.NewPlayCardResponse(false, player.Hand, 1, "Try discarding hand")
+		res.HandIndex = move.HandIndex
+		a.pushMessage(connID, res)
+		return
+	}
+
+	log.Printf("%v is playing: %v", player.Name, result.Card)
+	a.record(gameModel.PlayCardAction, playerID, move)
+	a.announceTurn(playerID, result)
+}
+
+// announceTurn tells the player how their turn went and the room what
+// happened to it
+func (a *roomActor) announceTurn(playerID string, result gameModel.TurnResult) {
+	gameRoom := a.room
+	connID := a.getConn(playerID)
+	player := gameRoom.PlayerMap[playerID]
+
+	if result.IsReshuffled {
+		reshuffleBroadcast := events.NewReshuffleBroadcast(len(gameRoom.Deck))
+		a.broadcast(reshuffleBroadcast)
+	}
+
+	if result.IsDead {
+		deadBroadcast := events.NewDeadPlayerBroadcast(player.PlayerID)
+		a.broadcast(deadBroadcast)
+	}
+
+	if result.Winner != nil {
+		a.broadcastEndGame(result.Winner)
+	}
+
+	message := ""
+	if !result.IsPlayed {
+		message = "Hand discarded"
+	}
+	res := events.NewPlayCardResponse(result.IsPlayed, player.Hand, 0, message)
+	a.pushMessage(connID, res)
+
+	broadcast := events.NewPlayCardBroadcast(result.Card, gameRoom.Count, gameRoom.IsClockwise, result.NextPlayerID)
+	a.broadcast(broadcast)
+	a.scheduleTurnTimer()
+}
+
+// scheduleTurnTimer starts counting down the turn of the current player,
+// replacing whatever countdown was running
+func (a *roomActor) scheduleTurnTimer() {
+	if a.turnTimer != nil {
+		a.turnTimer.Stop()
+		a.turnTimer = nil
+	}
+
+	if a.botTimer != nil {
+		a.botTimer.Stop()
+		a.botTimer = nil
+	}
+
+	gameRoom := a.room
+	if !gameRoom.IsStarted {
+		return
+	}
+
+	// bots always move on their own, a short turn timeout would otherwise
+	// catch them before they do
+	if player := gameRoom.PlayerMap[gameRoom.TurnID]; player != nil && player.IsBot {
+		a.scheduleBotMove(player.PlayerID)
+		return
+	}
+
+	a.pushLegalMoves(gameRoom.TurnID)
+
+	if gameRoom.Settings.TurnTimeout <= 0 {
+		return
+	}
+
+	playerID := gameRoom.TurnID
+	timeout := time.Duration(gameRoom.Settings.TurnTimeout) * time.Second
+
+	var timer *time.Timer
+	timer = time.AfterFunc(timeout, func() {
+		a.send(turnTimeoutCommand{playerID: playerID, timer: timer})
+	})
+	a.turnTimer = timer
+
+	broadcast := events.NewTurnTimerBroadcast(playerID, gameRoom.Settings.TurnTimeout, time.Now().Add(timeout))
+	a.broadcast(broadcast)
+}
+
+func (a *roomActor) pushLegalMoves(playerID string) {
+	res := events.NewLegalMovesResponse(a.room.LegalMoves(playerID))
+	a.pushMessage(a.getConn(playerID), res)
+}
+
+func (a *roomActor) timeoutTurn(playerID string, timer *time.Timer) {
+	gameRoom := a.room
+	if a.turnTimer != timer || !gameRoom.IsStarted || gameRoom.TurnID != playerID {
+		return
+	}
+	a.turnTimer = nil
+
+	log.Printf("player %v of room %v ran out of time", playerID, a.roomID)
+
+	if gameRoom.Settings.TurnPenalty == gameModel.EliminatePenalty {
+		a.eliminate(playerID)
+		return
+	}
+
+	a.forceDiscard(playerID)
+}
+
+// forceDiscard throws away the first card of an idle player
+func (a *roomActor) forceDiscard(playerID string) {
+	result, err := a.room.ForceDiscard(playerID)
+	if err != nil {
+		log.Printf("failed to discard for player %v: %v", playerID, err)
+		return
+	}
+
+	a.record(gameModel.ForceDiscardAction, playerID, nil)
+	a.announceTurn(playerID, result)
+}
+
+func (a *roomActor) eliminate(playerID string) {
+	gameRoom := a.room
+
+	result := gameRoom.Eliminate(playerID)
+	if !result.IsDead {
+		return
+	}
+	a.record(gameModel.EliminateAction, playerID, nil)
+
+	deadBroadcast := events.NewDeadPlayerBroadcast(playerID)
+	a.broadcast(deadBroadcast)
+
+	if result.Winner != nil {
+		a.broadcastEndGame(result.Winner)
+		return
+	}
+
+	broadcast := events.NewPlayCardBroadcast(gameModel.Card{}, gameRoom.Count, gameRoom.IsClockwise, result.NextPlayerID)
+	a.broadcast(broadcast)
+	a.scheduleTurnTimer()
+}
+
+func (a *roomActor) broadcastEndGame(winner *gameModel.Player) {
+	endBroadcast := events.NewEndGameBroadcast(winner)
+	if a.room.GameLog != nil {
+		endBroadcast.GameID = a.room.GameLog.GameID
+	}
+	a.broadcast(endBroadcast)
+	a.usecase.rateGame(a.roomID, a.accountRanking())
+
+	leaderboardBroadcast := events.NewLeaderboardBroadcast(a.room.GetLeaderboard())
+	a.broadcast(leaderboardBroadcast)
+
+	a.endRound()
+}
+
+// accountRanking lists the accounts of the last game in the order they
+// finished, guests and bots are left out
+func (a *roomActor) accountRanking() []string {
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, playerID := range a.room.Ranking {
+		player := a.room.Finisher(playerID)
+		if player == nil || player.AccountID == "" || seen[player.AccountID] {
+			continue
+		}
+
+		seen[player.AccountID] = true
+		result = append(result, player.AccountID)
+	}
+
+	return result
+}
+
+func (a *roomActor) pushLeaderboard(connID string) {
+	if _, ok := a.members[connID]; !ok {
+		return
+	}
+
+	res := events.NewLeaderboardResponse(a.room.GetLeaderboard())
+	a.pushMessage(connID, res)
+}
+
+// endRound scores the game that just ended when it is a round of a match,
+// then either ends the match or counts down to the next round
+func (a *roomActor) endRound() {
+	gameRoom := a.room
+	match := gameRoom.Match
+	if match == nil {
+		return
+	}
+
+	points := match.AddRound(gameRoom.Ranking)
+	standings := match.Standings(gameRoom, points)
+
+	if match.Finish(gameRoom) {
+		log.Printf("room %v finished its match after %v rounds", a.roomID, match.Round)
+		broadcast := events.NewMatchEndBroadcast(match, standings)
+		a.broadcast(broadcast)
+		gameRoom.Match = nil
+		return
+	}
+
+	nextRoundAt := time.Now().Add(roundCountdown)
+	var timer *time.Timer
+	timer = time.AfterFunc(roundCountdown, func() {
+		a.send(nextRoundCommand{timer: timer})
+	})
+	a.roundTimer = timer
+
+	broadcast := events.NewRoundEndBroadcast(match.Round, standings, nextRoundAt)
+	a.broadcast(broadcast)
+}
+
+// pushRoomState sends a member a snapshot of the room, whether they asked
+// for it or reported a gap in the broadcasts they got
+func (a *roomActor) pushRoomState(connID string, gap *events.SequenceGapPayload) {
+	if gap != nil {
+		log.Printf("connection %v of room %v missed broadcasts after %v, now at %v", connID, a.roomID, gap.LastSeq, a.room.Seq)
+	}
+
+	res := events.NewRoomStateResponse(gameModel.NewRoomState(a.room, a.members[connID]))
+	a.pushMessage(connID, res)
+}
+
+// startNextRound starts the next round of the match once the countdown is
+// over, unless too many players have left in the meantime
+func (a *roomActor) startNextRound(timer *time.Timer) {
+	gameRoom := a.room
+	if a.roundTimer != timer || gameRoom.IsStarted || gameRoom.Match == nil {
+		return
+	}
+	a.roundTimer = nil
+
+	if len(gameRoom.Players) < 2 {
+		log.Printf("room %v abandoned its match, not enough players", a.roomID)
+		gameRoom.Match = nil
+		return
+	}
+
+	a.beginGame()
+}
+
+// updateRoomSettings lets the host change the settings of the room before a
+// game starts
+func (a *roomActor) updateRoomSettings(connID string, payload *events.UpdateRoomSettingsPayload) {
+	if err := a.room.UpdateSettings(payload.Settings); err != nil {
+		res := events.NewUpdateRoomSettingsResponse(false, err.Error())
+		a.pushMessage(connID, res)
+		return
+	}
+
+	res := events.NewUpdateRoomSettingsResponse(true, "")
+	a.pushMessage(connID, res)
+
+	broadcast := events.NewUpdateRoomSettingsBroadcast(a.room)
+	a.broadcast(broadcast)
+}
+
+// addBot lets the host fill a seat with a bot
+func (a *roomActor) addBot(connID string, payload *events.AddBotPayload) {
+	if a.room.IsFull() {
+		res := events.NewAddBotResponse(false, gameModel.ErrRoomFull.Error())
+		a.pushMessage(connID, res)
+		return
+	}
+
+	strategy, err := gameModel.NewStrategy(payload.Strategy)
+	if err != nil {
+		res := events.NewAddBotResponse(false, err.Error())
+		a.pushMessage(connID, res)
+		return
+	}
+
+	name := ""
+	for i := 1; name == "" || a.room.IsUsernameExist(name); i++ {
+		name = fmt.Sprintf("Bot %v", i)
+	}
+
+	bot := gameModel.NewBot(name, strategy.Name())
+	a.room.AddPlayer(bot)
+	a.strategies[bot.PlayerID] = strategy
+
+	res := events.NewAddBotResponse(true, "")
+	a.pushMessage(connID, res)
+
+	broadcast := events.NewJoinRoomBroadcast(bot)
+	a.broadcast(broadcast)
+}
+
+func (a *roomActor) removeBot(connID string, payload *events.TargetPayload) {
+	bot := a.room.PlayerMap[payload.PlayerID]
+	if bot == nil || !bot.IsBot {
+		res := events.NewRemoveBotResponse(false, "Bot not found")
+		a.pushMessage(connID, res)
+		return
+	}
+
+	res := events.NewRemoveBotResponse(true, "")
+	a.pushMessage(connID, res)
+
+	a.removePlayer(bot.PlayerID)
+	delete(a.strategies, bot.PlayerID)
+}
+
+// scheduleBotMove lets a bot think for a while before it moves, the way a
+// human would
+func (a *roomActor) scheduleBotMove(playerID string) {
+	delay := botMinDelay + time.Duration(rand.Int63n(int64(botMaxDelay-botMinDelay)))
+
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		a.send(botMoveCommand{playerID: playerID, timer: timer})
+	})
+	a.botTimer = timer
+}
+
+func (a *roomActor) moveBot(playerID string, timer *time.Timer) {
+	gameRoom := a.room
+	if a.botTimer != timer || !gameRoom.IsStarted || gameRoom.TurnID != playerID {
+		return
+	}
+	a.botTimer = nil
+
+	strategy, ok := a.strategies[playerID]
+	if !ok {
+		var err error
+		strategy, err = gameModel.NewStrategy(gameRoom.PlayerMap[playerID].Strategy)
+		if err != nil {
+			log.Printf("bot %v of room %v has an unknown strategy: %v", playerID, a.roomID, err)
+			strategy, _ = gameModel.NewStrategy(gameModel.RandomStrategy)
+		}
+		a.strategies[playerID] = strategy
+	}
+
+	a.playTurn(playerID, strategy.ChooseMove(gameRoom, playerID))
+}
+
+func (a *roomActor) broadcastChat(connID string, payload *events.ChatPayload) {
+	log.Printf("Client is sending chat on room %v", a.roomID)
+
+	playerName := a.getMember(a.members[connID]).Name
+
+	log.Printf("player %s send chat", playerName)
+	broadcast := events.NewMessageBroadcast(payload.Message, playerName)
+	a.broadcast(broadcast)
+}
+
+func (a *roomActor) dealCard() {
+	for connID, playerID := range a.members {
+		player := a.room.PlayerMap[playerID]
+		if player == nil {
+			continue
+		}
+		message := events.NewInitialHandResponse(player.Hand)
+		a.pushMessage(connID, message)
+	}
+}
+
+func (a *roomActor) registerPlayer(connID string, player *gameModel.Player) {
+	a.room.AddPlayer(player)
+	a.members[connID] = player.PlayerID
+}
+
+// newMember builds whoever is joining the room as the identity their
+// connection was let in as
+func newMember(identity gameModel.Identity, name, avatarURL string) *gameModel.Player {
+	player := gameModel.NewPlayer(name, avatarURL)
+	player.PlayerID = identity.PlayerID
+	player.AccountID = identity.AccountID
+	player.IP = identity.IP
+
+	return player
+}
+
+func (a *roomActor) sessionToken(playerID string) string {
+	return token.Sign(configs.Session.Secret, a.roomID, playerID)
+}
+
+// unregisterConn stops delivering to a member, their connection is closed
+// once whatever has been sent to it is flushed
+func (a *roomActor) unregisterConn(connID string) {
+	if _, ok := a.members[connID]; !ok {
+		return
+	}
+
+	delete(a.members, connID)
+	a.usecase.publish(a.roomID, roomMessage{ConnIDs: []string{connID}, Close: true})
+}
+
+// getMember looks a member up among both the players and the spectators
+func (a *roomActor) getMember(playerID string) *gameModel.Player {
+	if player := a.room.PlayerMap[playerID]; player != nil {
+		return player
+	}
+
+	return a.room.GetSpectator(playerID)
+}
+
+func (a *roomActor) getConn(playerID string) string {
+	for connID, memberID := range a.members {
+		if memberID == playerID {
+			return connID
+		}
+	}
+
+	return ""
+}
+
+func (a *roomActor) pushMessage(connID string, message interface{}) {
+	if connID == "" {
+		return
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("room %v failed to encode %T: %v", a.roomID, message, err)
+		return
+	}
+
+	requestID := ""
+	if connID == a.origin.connID {
+		requestID = a.origin.request.RequestID
+	}
+
+	if requestID != "" {
+		data, err = events.WithRequestID(data, requestID)
+		if err != nil {
+			log.Printf("room %v failed to stamp %T: %v", a.roomID, message, err)
+			return
+		}
+	}
+
+	a.usecase.publish(a.roomID, roomMessage{ConnIDs: []string{connID}, Message: data, RequestID: requestID})
+}
+
+func (a *roomActor) broadcast(message interface{}) {
+	connIDs := []string{}
+	for connID := range a.members {
+		connIDs = append(connIDs, connID)
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("room %v failed to encode %T: %v", a.roomID, message, err)
+		return
+	}
+
+	a.room.Seq++
+	data, err = events.WithSeq(data, a.room.Seq)
+	if err != nil {
+		log.Printf("room %v failed to stamp %T: %v", a.roomID, message, err)
+		return
+	}
+
+	if gameLog := a.room.GameLog; gameLog != nil {
+		var header struct {
+			EventType string `json:"event_type"`
+		}
+		json.Unmarshal(data, &header)
+		if entry, err := gameLog.AddBroadcast(header.EventType, data); err == nil {
+			a.usecase.appendLog(a.roomID, entry)
+		}
+	}
+
+	a.publish(connIDs, data)
+}
+
+// record appends a command the room has accepted to the log of the game
+func (a *roomActor) record(action, playerID string, payload interface{}) {
+	gameLog := a.room.GameLog
+	if gameLog == nil {
+		return
+	}
+
+	entry, err := gameLog.AddCommand(action, playerID, payload)
+	if err != nil {
+		log.Printf("room %v failed to record %v: %v", a.roomID, action, err)
+		return
+	}
+
+	a.usecase.appendLog(a.roomID, entry)
+}
+
+// finishGameLog stores the log of the game once it is over
+func (a *roomActor) finishGameLog() {
+	gameLog := a.room.GameLog
+	if gameLog == nil || a.room.IsStarted {
+		return
+	}
+
+	gameLog.EndedAt = time.Now()
+	a.usecase.saveReplay(gameLog)
+	a.room.GameLog = nil
+}
+
+func (a *roomActor) publish(connIDs []string, data json.RawMessage) {
+	if len(connIDs) == 0 {
+		return
+	}
+
+	a.usecase.publish(a.roomID, roomMessage{ConnIDs: connIDs, Message: data})
+}
@@ -0,0 +1,75 @@
+package usecases
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aryuuu/cepex-server/configs"
+	gameModel "github.com/aryuuu/cepex-server/models/game"
+	"github.com/aryuuu/cepex-server/utils/token"
+	"github.com/google/uuid"
+)
+
+const (
+	ticketKind = "ticket"
+	// ticketTTL only has to cover the time between asking for a ticket and
+	// opening the connection
+	ticketTTL = 5 * time.Minute
+)
+
+// IssueTicket lets someone connect to a room. Guests get a new identity
+// unless they hold a session token of the room, in which case they get
+// their seat back
+func (u *gameUsecase) IssueTicket(roomID, sessionToken, accountID string) (*gameModel.Ticket, error) {
+	playerID := uuid.NewString()
+	if sessionToken != "" {
+		fields, err := token.Verify(configs.Session.Secret, sessionToken)
+		if err != nil || len(fields) != 2 || fields[0] != roomID {
+			return nil, gameModel.ErrInvalidTicket
+		}
+		playerID = fields[1]
+	}
+
+	expiresAt := time.Now().Add(ticketTTL)
+	signed := token.Sign(
+		configs.Session.Secret,
+		ticketKind,
+		roomID,
+		playerID,
+		accountID,
+		strconv.FormatInt(expiresAt.Unix(), 10),
+	)
+
+	result := &gameModel.Ticket{
+		Token:     signed,
+		RoomID:    roomID,
+		PlayerID:  playerID,
+		ExpiresAt: expiresAt,
+	}
+
+	return result, nil
+}
+
+// VerifyTicket tells who the bearer of the ticket may connect to the room as
+func (u *gameUsecase) VerifyTicket(roomID, ticket string) (gameModel.Identity, error) {
+	fields, err := token.Verify(configs.Session.Secret, ticket)
+	if err != nil || len(fields) != 5 || fields[0] != ticketKind {
+		return gameModel.Identity{}, gameModel.ErrInvalidTicket
+	}
+
+	expiresAt, err := strconv.ParseInt(fields[4], 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return gameModel.Identity{}, gameModel.ErrInvalidTicket
+	}
+
+	if fields[1] != roomID {
+		return gameModel.Identity{}, gameModel.ErrWrongRoom
+	}
+
+	result := gameModel.Identity{
+		PlayerID:  fields[2],
+		AccountID: fields[3],
+	}
+
+	return result, nil
+}
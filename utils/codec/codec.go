@@ -0,0 +1,43 @@
+package codec
+
+import (
+	"encoding/json"
+
+	"github.com/aryuuu/cepex-server/models/events"
+	"github.com/gorilla/websocket"
+)
+
+// Subprotocols lists the subprotocols the server speaks, in the order it
+// prefers them
+var Subprotocols = []string{
+	events.ProtoSubprotocol,
+	events.MsgpackSubprotocol,
+	events.JSONSubprotocol,
+}
+
+// New returns the codec of a negotiated subprotocol, JSON when there is none
+func New(subprotocol string) events.Codec {
+	switch subprotocol {
+	case events.MsgpackSubprotocol:
+		return msgpackCodec{}
+	case events.ProtoSubprotocol:
+		return protoCodec{}
+	default:
+		return jsonCodec{}
+	}
+}
+
+// jsonCodec sends messages as they are
+type jsonCodec struct{}
+
+func (jsonCodec) FrameType() int {
+	return websocket.TextMessage
+}
+
+func (jsonCodec) DecodeRequest(frame []byte) (json.RawMessage, error) {
+	return frame, nil
+}
+
+func (jsonCodec) EncodeMessage(message json.RawMessage) ([]byte, error) {
+	return message, nil
+}
@@ -0,0 +1,255 @@
+package codec
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aryuuu/cepex-server/models/events"
+	"github.com/aryuuu/cepex-server/models/game"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func sampleMessages() []interface{} {
+	host := game.NewPlayer("host", "avatar")
+	room := game.NewRoom("ROOM1", host.PlayerID, 4)
+	room.AddPlayer(host)
+	room.AddPlayer(game.NewBot("bot", game.GreedyStrategy))
+	room.Seq = 12
+	room.StartGameWithSeed(1)
+
+	deadline := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	tally := game.VoteTally{TargetID: host.PlayerID, Yes: 1, Electorate: 2, Needed: 2, ExpiresAt: deadline}
+
+	return []interface{}{
+		events.NewCreateRoomResponse(true, room, ""),
+		events.NewJoinRoomBroadcast(host),
+		events.NewPlayCardResponse(false, host.Hand, 1, "Try discarding hand"),
+		events.NewPlayCardBroadcast(game.Card{Pattern: 2, Rank: 7}, 98, true, host.PlayerID),
+		events.NewTurnTimerBroadcast(host.PlayerID, 30, deadline),
+		events.NewVoteKickTallyBroadcast(tally),
+		events.NewRoomStateResponse(game.NewRoomState(room, host.PlayerID)),
+		events.NewErrorResponse(events.CodeUnauthorized, events.StartGameEvent, "7", "Only the host can do that"),
+		events.NewHostActionResponse(events.BanPlayerEvent, true, ""),
+		events.NewLeaderboardBroadcast(room.GetLeaderboard()),
+	}
+}
+
+func stamp(t *testing.T, message interface{}) json.RawMessage {
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = events.WithSeq(data, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = events.WithRequestID(data, "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+// decodeFrame is what a client does with a frame of the proto codec
+func decodeFrame(t *testing.T, data []byte) json.RawMessage {
+	frame := dynamicpb.NewMessage(protoSchema.frame)
+	if err := proto.Unmarshal(data, frame); err != nil {
+		t.Fatal(err)
+	}
+
+	fields := protoSchema.frame.Fields()
+	eventType := frame.Get(fields.ByName("event_type")).String()
+	body := dynamicpb.NewMessage(protoSchema.messages[eventType])
+	if err := proto.Unmarshal(frame.Get(fields.ByName("body")).Bytes(), body); err != nil {
+		t.Fatal(err)
+	}
+
+	document := fromMessage(body)
+	document["event_type"] = eventType
+	document["id"] = frame.Get(fields.ByName("id")).String()
+	document["seq"] = frame.Get(fields.ByName("seq")).Uint()
+
+	result, err := json.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+// sameMessage compares two encodings of a message the way the struct they
+// decode into sees them
+func sameMessage(t *testing.T, exp, act json.RawMessage) {
+	var header struct {
+		EventType string `json:"event_type"`
+		ID        string `json:"id"`
+		Seq       uint64 `json:"seq"`
+	}
+	json.Unmarshal(exp, &header)
+	messageType, _ := events.MessageType(header.EventType)
+
+	decode := func(data json.RawMessage) interface{} {
+		message := reflect.New(messageType).Interface()
+		if err := json.Unmarshal(data, message); err != nil {
+			t.Fatal(err)
+		}
+		normalized, _ := json.Marshal(message)
+
+		var result interface{}
+		json.Unmarshal(normalized, &result)
+		return dropEmpty(result)
+	}
+
+	var actHeader struct {
+		EventType string `json:"event_type"`
+		ID        string `json:"id"`
+		Seq       uint64 `json:"seq"`
+	}
+	json.Unmarshal(act, &actHeader)
+
+	if header != actHeader || !reflect.DeepEqual(decode(exp), decode(act)) {
+		t.Fatalf("exp: %s\ngot: %s", exp, act)
+	}
+}
+
+// dropEmpty removes the empty lists and maps protobuf can not tell from
+// missing ones
+func dropEmpty(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			field = dropEmpty(field)
+			if field == nil {
+				delete(v, key)
+			} else {
+				v[key] = field
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		for i, item := range v {
+			v[i] = dropEmpty(item)
+		}
+	}
+
+	return value
+}
+
+func TestEncodeMessage(t *testing.T) {
+	for _, message := range sampleMessages() {
+		data := stamp(t, message)
+
+		frame, err := New(events.JSONSubprotocol).EncodeMessage(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sameMessage(t, data, frame)
+
+		frame, err = New(events.MsgpackSubprotocol).EncodeMessage(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var document interface{}
+		if err := msgpack.Unmarshal(frame, &document); err != nil {
+			t.Fatal(err)
+		}
+		unpacked, _ := json.Marshal(document)
+		sameMessage(t, data, unpacked)
+
+		frame, err = New(events.ProtoSubprotocol).EncodeMessage(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sameMessage(t, data, decodeFrame(t, frame))
+		if len(frame) >= len(data) {
+			t.Fatalf("proto frame of %T is %v bytes, JSON is %v", message, len(frame), len(data))
+		}
+	}
+}
+
+func TestEncodeUnknownMessage(t *testing.T) {
+	_, err := New(events.ProtoSubprotocol).EncodeMessage(json.RawMessage(`{"event_type":"unknown"}`))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestDecodeRequest(t *testing.T) {
+	request := json.RawMessage(`{"v":1,"type":"play-card","id":"9","payload":{"hand_index":1,"is_add":true,"id_target":"p2"}}`)
+	exp, err := events.DecodeRequest(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packed, _ := msgpack.Marshal(map[string]interface{}{
+		"v":       1,
+		"type":    "play-card",
+		"id":      "9",
+		"payload": map[string]interface{}{"hand_index": 1, "is_add": true, "id_target": "p2"},
+	})
+
+	payload, err := toMessage(protoSchema.payloads[events.PlayCardEvent], map[string]interface{}{"hand_index": int64(1), "is_add": true, "id_target": "p2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payloadData, _ := proto.Marshal(payload)
+
+	fields := protoSchema.envelope.Fields()
+	envelope := dynamicpb.NewMessage(protoSchema.envelope)
+	envelope.Set(fields.ByName("v"), protoreflect.ValueOfUint32(1))
+	envelope.Set(fields.ByName("type"), protoreflect.ValueOfString(events.PlayCardEvent))
+	envelope.Set(fields.ByName("id"), protoreflect.ValueOfString("9"))
+	envelope.Set(fields.ByName("payload"), protoreflect.ValueOfBytes(payloadData))
+	envelopeData, _ := proto.Marshal(envelope)
+
+	frames := map[string][]byte{
+		events.JSONSubprotocol:    request,
+		events.MsgpackSubprotocol: packed,
+		events.ProtoSubprotocol:   envelopeData,
+	}
+	for subprotocol, frame := range frames {
+		data, err := New(subprotocol).DecodeRequest(frame)
+		if err != nil {
+			t.Fatalf("%v: %v", subprotocol, err)
+		}
+
+		act, err := events.DecodeRequest(data)
+		if err != nil {
+			t.Fatalf("%v: %v", subprotocol, err)
+		}
+		if !reflect.DeepEqual(exp, act) {
+			t.Fatalf("%v: exp %#v, got %#v", subprotocol, exp, act)
+		}
+	}
+
+	if _, err := New(events.ProtoSubprotocol).DecodeRequest([]byte("not proto")); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestProtoSchema(t *testing.T) {
+	for _, eventType := range events.MessageTypes() {
+		if protoSchema.messages[eventType] == nil {
+			t.Fatalf("no schema for %v", eventType)
+		}
+	}
+
+	for _, eventType := range events.RequestTypes() {
+		if protoSchema.payloads[eventType] == nil {
+			t.Fatalf("no schema for request %v", eventType)
+		}
+	}
+}
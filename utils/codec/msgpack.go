@@ -0,0 +1,79 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// msgpackCodec sends the same documents as JSON does, packed with
+// MessagePack
+type msgpackCodec struct{}
+
+func (msgpackCodec) FrameType() int {
+	return websocket.BinaryMessage
+}
+
+func (msgpackCodec) DecodeRequest(frame []byte) (json.RawMessage, error) {
+	var document interface{}
+	if err := msgpack.Unmarshal(frame, &document); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(document)
+}
+
+func (msgpackCodec) EncodeMessage(message json.RawMessage) ([]byte, error) {
+	document, err := decodeJSON(message)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	encoder := msgpack.NewEncoder(&b)
+	encoder.UseCompactInts(true)
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// decodeJSON decodes a document keeping whole numbers whole, so that they
+// are not packed as floats
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	return unwrapNumbers(document), nil
+}
+
+func unwrapNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, field := range v {
+			v[key] = unwrapNumbers(field)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = unwrapNumbers(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
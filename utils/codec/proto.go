@@ -0,0 +1,241 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protoCodec sends the messages of the protocol schema, requests come in an
+// Envelope and messages go out in a Frame
+type protoCodec struct{}
+
+func (protoCodec) FrameType() int {
+	return websocket.BinaryMessage
+}
+
+func (protoCodec) DecodeRequest(frame []byte) (json.RawMessage, error) {
+	envelope := dynamicpb.NewMessage(protoSchema.envelope)
+	if err := proto.Unmarshal(frame, envelope); err != nil {
+		return nil, err
+	}
+
+	fields := protoSchema.envelope.Fields()
+	eventType := envelope.Get(fields.ByName("type")).String()
+	document := map[string]interface{}{
+		"v":    envelope.Get(fields.ByName("v")).Uint(),
+		"type": eventType,
+		"id":   envelope.Get(fields.ByName("id")).String(),
+	}
+
+	// requests of unknown types are let through for the envelope to reject
+	if desc, ok := protoSchema.payloads[eventType]; ok {
+		payload := dynamicpb.NewMessage(desc)
+		if err := proto.Unmarshal(envelope.Get(fields.ByName("payload")).Bytes(), payload); err != nil {
+			return nil, err
+		}
+		document["payload"] = fromMessage(payload)
+	}
+
+	return json.Marshal(document)
+}
+
+func (protoCodec) EncodeMessage(message json.RawMessage) ([]byte, error) {
+	decoded, err := decodeJSON(message)
+	if err != nil {
+		return nil, err
+	}
+
+	document, ok := decoded.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("message is not an object")
+	}
+
+	eventType, _ := document["event_type"].(string)
+	desc, ok := protoSchema.messages[eventType]
+	if !ok {
+		return nil, fmt.Errorf("no schema for event %q", eventType)
+	}
+
+	body, err := toMessage(desc, document)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", eventType, err)
+	}
+
+	data, err := proto.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := protoSchema.frame.Fields()
+	frame := dynamicpb.NewMessage(protoSchema.frame)
+	frame.Set(fields.ByName("event_type"), protoreflect.ValueOfString(eventType))
+	if id, ok := document["id"].(string); ok {
+		frame.Set(fields.ByName("id"), protoreflect.ValueOfString(id))
+	}
+	if seq, ok := document["seq"].(int64); ok {
+		frame.Set(fields.ByName("seq"), protoreflect.ValueOfUint64(uint64(seq)))
+	}
+	frame.Set(fields.ByName("body"), protoreflect.ValueOfBytes(data))
+
+	return proto.Marshal(frame)
+}
+
+// toMessage fills a message with a decoded JSON document, fields the
+// message does not know are left out
+func toMessage(desc protoreflect.MessageDescriptor, document map[string]interface{}) (*dynamicpb.Message, error) {
+	message := dynamicpb.NewMessage(desc)
+
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		value, ok := document[field.JSONName()]
+		if !ok || value == nil {
+			continue
+		}
+
+		switch {
+		case field.IsMap():
+			entries, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%v should be an object", field.Name())
+			}
+
+			m := message.Mutable(field).Map()
+			for key, entry := range entries {
+				mapKey, err := toMapKey(field.MapKey(), key)
+				if err != nil {
+					return nil, err
+				}
+				mapValue, err := toValue(field.MapValue(), entry)
+				if err != nil {
+					return nil, err
+				}
+				m.Set(mapKey, mapValue)
+			}
+		case field.IsList():
+			items, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%v should be a list", field.Name())
+			}
+
+			list := message.Mutable(field).List()
+			for _, item := range items {
+				listValue, err := toValue(field, item)
+				if err != nil {
+					return nil, err
+				}
+				list.Append(listValue)
+			}
+		default:
+			fieldValue, err := toValue(field, value)
+			if err != nil {
+				return nil, err
+			}
+			message.Set(field, fieldValue)
+		}
+	}
+
+	return message, nil
+}
+
+func toValue(field protoreflect.FieldDescriptor, value interface{}) (protoreflect.Value, error) {
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		if v, ok := value.(string); ok {
+			return protoreflect.ValueOfString(v), nil
+		}
+	case protoreflect.BoolKind:
+		if v, ok := value.(bool); ok {
+			return protoreflect.ValueOfBool(v), nil
+		}
+	case protoreflect.Sint64Kind:
+		if v, ok := value.(int64); ok {
+			return protoreflect.ValueOfInt64(v), nil
+		}
+	case protoreflect.Uint64Kind:
+		if v, ok := value.(int64); ok && v >= 0 {
+			return protoreflect.ValueOfUint64(uint64(v)), nil
+		}
+	case protoreflect.DoubleKind:
+		switch v := value.(type) {
+		case int64:
+			return protoreflect.ValueOfFloat64(float64(v)), nil
+		case float64:
+			return protoreflect.ValueOfFloat64(v), nil
+		}
+	case protoreflect.MessageKind:
+		if v, ok := value.(map[string]interface{}); ok {
+			message, err := toMessage(field.Message(), v)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfMessage(message), nil
+		}
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("%v can not hold %v", field.FullName(), value)
+}
+
+func toMapKey(field protoreflect.FieldDescriptor, key string) (protoreflect.MapKey, error) {
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(key).MapKey(), nil
+	case protoreflect.Sint64Kind:
+		n, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, err
+		}
+		return protoreflect.ValueOfInt64(n).MapKey(), nil
+	default:
+		return protoreflect.MapKey{}, fmt.Errorf("%v can not be a map key", field.Kind())
+	}
+}
+
+// fromMessage turns a message back into a document encoding/json can read
+func fromMessage(message protoreflect.Message) map[string]interface{} {
+	document := make(map[string]interface{})
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case field.IsMap():
+			entries := make(map[string]interface{})
+			value.Map().Range(func(key protoreflect.MapKey, entry protoreflect.Value) bool {
+				entries[key.String()] = fromValue(field.MapValue(), entry)
+				return true
+			})
+			document[field.JSONName()] = entries
+		case field.IsList():
+			list := value.List()
+			items := make([]interface{}, list.Len())
+			for i := range items {
+				items[i] = fromValue(field, list.Get(i))
+			}
+			document[field.JSONName()] = items
+		default:
+			document[field.JSONName()] = fromValue(field, value)
+		}
+		return true
+	})
+
+	return document
+}
+
+func fromValue(field protoreflect.FieldDescriptor, value protoreflect.Value) interface{} {
+	switch field.Kind() {
+	case protoreflect.MessageKind:
+		return fromMessage(value.Message())
+	case protoreflect.Sint64Kind:
+		return value.Int()
+	case protoreflect.Uint64Kind, protoreflect.Uint32Kind:
+		return value.Uint()
+	case protoreflect.DoubleKind:
+		return value.Float()
+	default:
+		return value.Interface()
+	}
+}
@@ -0,0 +1,352 @@
+package codec
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aryuuu/cepex-server/models/events"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const protoPackage = "cepex.v1"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema holds the protobuf messages of the protocol, they are built from
+// the structs of the events package. Fields are numbered in the order they
+// are declared in, new fields have to be added after the existing ones
+type schema struct {
+	file     protoreflect.FileDescriptor
+	envelope protoreflect.MessageDescriptor
+	frame    protoreflect.MessageDescriptor
+	// messages is keyed by the event type the server sends
+	messages map[string]protoreflect.MessageDescriptor
+	// payloads is keyed by the event type clients send
+	payloads map[string]protoreflect.MessageDescriptor
+}
+
+var protoSchema = mustBuildSchema()
+
+func mustBuildSchema() *schema {
+	result, err := buildSchema()
+	if err != nil {
+		panic(fmt.Sprintf("codec: failed to build protobuf schema: %v", err))
+	}
+
+	return result
+}
+
+func buildSchema() (*schema, error) {
+	b := &schemaBuilder{types: make(map[reflect.Type]string), names: make(map[string]reflect.Type)}
+	b.file = &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("cepex.proto"),
+		Package: proto.String(protoPackage),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Envelope"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					scalarField("v", 1, descriptorpb.FieldDescriptorProto_TYPE_UINT32),
+					scalarField("type", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					scalarField("id", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					scalarField("payload", 4, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+				},
+			},
+			{
+				Name: proto.String("Frame"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					scalarField("event_type", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					scalarField("id", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					scalarField("seq", 3, descriptorpb.FieldDescriptorProto_TYPE_UINT64),
+					scalarField("body", 4, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+				},
+			},
+		},
+	}
+	b.names["Envelope"] = nil
+	b.names["Frame"] = nil
+
+	messageNames := make(map[string]string)
+	for _, eventType := range events.MessageTypes() {
+		t, _ := events.MessageType(eventType)
+		name, err := b.message(t)
+		if err != nil {
+			return nil, err
+		}
+		messageNames[eventType] = name
+	}
+
+	payloadNames := make(map[string]string)
+	for _, eventType := range events.RequestTypes() {
+		t, _ := events.PayloadType(eventType)
+		name, err := b.message(t)
+		if err != nil {
+			return nil, err
+		}
+		payloadNames[eventType] = name
+	}
+
+	file, err := protodesc.NewFile(b.file, new(protoregistry.Files))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &schema{
+		file:     file,
+		envelope: file.Messages().ByName("Envelope"),
+		frame:    file.Messages().ByName("Frame"),
+		messages: make(map[string]protoreflect.MessageDescriptor),
+		payloads: make(map[string]protoreflect.MessageDescriptor),
+	}
+	for eventType, name := range messageNames {
+		result.messages[eventType] = file.Messages().ByName(protoreflect.Name(name))
+	}
+	for eventType, name := range payloadNames {
+		result.payloads[eventType] = file.Messages().ByName(protoreflect.Name(name))
+	}
+
+	return result, nil
+}
+
+type schemaBuilder struct {
+	file  *descriptorpb.FileDescriptorProto
+	types map[reflect.Type]string
+	names map[string]reflect.Type
+}
+
+// message adds the message of a struct to the schema, it returns the name
+// of the message
+func (b *schemaBuilder) message(t reflect.Type) (string, error) {
+	if name, ok := b.types[t]; ok {
+		return name, nil
+	}
+
+	name := t.Name()
+	if other, ok := b.names[name]; ok {
+		return "", fmt.Errorf("%v and %v are both named %v", t, other, name)
+	}
+	b.types[t] = name
+	b.names[name] = t
+
+	message := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	b.file.MessageType = append(b.file.MessageType, message)
+
+	for i, field := range jsonFields(t) {
+		fieldProto := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(field.name),
+			JsonName: proto.String(field.name),
+			Number:   proto.Int32(int32(i + 1)),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if err := b.setType(message, fieldProto, field.typ); err != nil {
+			return "", fmt.Errorf("%v.%v: %v", name, field.name, err)
+		}
+		message.Field = append(message.Field, fieldProto)
+	}
+
+	return name, nil
+}
+
+func (b *schemaBuilder) setType(owner *descriptorpb.DescriptorProto, field *descriptorpb.FieldDescriptorProto, t reflect.Type) error {
+	if t == timeType {
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return b.setType(owner, field, t.Elem())
+	case reflect.String:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	case reflect.Bool:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_BOOL.Enum()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_SINT64.Enum()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_UINT64.Enum()
+	case reflect.Float32, reflect.Float64:
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_DOUBLE.Enum()
+	case reflect.Struct:
+		name, err := b.message(t)
+		if err != nil {
+			return err
+		}
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+		field.TypeName = proto.String("." + protoPackage + "." + name)
+	case reflect.Slice, reflect.Array:
+		if field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+			return fmt.Errorf("nested lists are not supported")
+		}
+		field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		return b.setType(owner, field, t.Elem())
+	case reflect.Map:
+		if field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+			return fmt.Errorf("lists of maps are not supported")
+		}
+
+		entry := &descriptorpb.DescriptorProto{
+			Name:    proto.String(mapEntryName(field.GetName())),
+			Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+		}
+		key := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String("key"),
+			JsonName: proto.String("key"),
+			Number:   proto.Int32(1),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if err := b.setType(entry, key, t.Key()); err != nil {
+			return err
+		}
+		value := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String("value"),
+			JsonName: proto.String("value"),
+			Number:   proto.Int32(2),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if err := b.setType(entry, value, t.Elem()); err != nil {
+			return err
+		}
+		entry.Field = []*descriptorpb.FieldDescriptorProto{key, value}
+		owner.NestedType = append(owner.NestedType, entry)
+
+		field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+		field.TypeName = proto.String("." + protoPackage + "." + owner.GetName() + "." + entry.GetName())
+	default:
+		return fmt.Errorf("%v is not supported", t)
+	}
+
+	return nil
+}
+
+type jsonField struct {
+	name string
+	typ  reflect.Type
+}
+
+// jsonFields lists the fields of a struct the way encoding/json sees them,
+// the event type is left out as frames carry it
+func jsonFields(t reflect.Type) []jsonField {
+	result := []jsonField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				result = append(result, jsonFields(embedded)...)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if name == "event_type" {
+			continue
+		}
+
+		result = append(result, jsonField{name: name, typ: field.Type})
+	}
+
+	return result
+}
+
+func scalarField(name string, number int32, fieldType descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(number),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     fieldType.Enum(),
+	}
+}
+
+// mapEntryName names the entry of a map field the way protoc does
+func mapEntryName(fieldName string) string {
+	var b strings.Builder
+	isUpper := true
+	for _, c := range fieldName {
+		switch {
+		case c == '_':
+			isUpper = true
+		case isUpper:
+			b.WriteRune(unicode.ToUpper(c))
+			isUpper = false
+		default:
+			b.WriteRune(c)
+		}
+	}
+	b.WriteString("Entry")
+
+	return b.String()
+}
+
+// ProtoSchema returns the protobuf schema of the protocol as a .proto file,
+// for clients to generate their code from
+func ProtoSchema() string {
+	var b strings.Builder
+	b.WriteString("// Code generated by the cepex server. DO NOT EDIT.\n")
+	b.WriteString("//\n")
+	b.WriteString("// Clients send an Envelope whose payload is the message of its type,\n")
+	b.WriteString("// the server sends a Frame whose body is the message of its event type.\n")
+	b.WriteString("//\n")
+	b.WriteString("// Requests:\n")
+	for _, eventType := range events.RequestTypes() {
+		fmt.Fprintf(&b, "//   %v: %v\n", eventType, protoSchema.payloads[eventType].Name())
+	}
+	b.WriteString("//\n")
+	b.WriteString("// Events:\n")
+	for _, eventType := range events.MessageTypes() {
+		fmt.Fprintf(&b, "//   %v: %v\n", eventType, protoSchema.messages[eventType].Name())
+	}
+	fmt.Fprintf(&b, "\nsyntax = \"proto3\";\n\npackage %v;\n", protoPackage)
+
+	messages := protoSchema.file.Messages()
+	for i := 0; i < messages.Len(); i++ {
+		message := messages.Get(i)
+		fmt.Fprintf(&b, "\nmessage %v {\n", message.Name())
+
+		fields := message.Fields()
+		for j := 0; j < fields.Len(); j++ {
+			field := fields.Get(j)
+			switch {
+			case field.IsMap():
+				fmt.Fprintf(&b, "  map<%v, %v> %v = %v;\n", typeName(field.MapKey()), typeName(field.MapValue()), field.Name(), field.Number())
+			case field.IsList():
+				fmt.Fprintf(&b, "  repeated %v %v = %v;\n", typeName(field), field.Name(), field.Number())
+			default:
+				fmt.Fprintf(&b, "  %v %v = %v;\n", typeName(field), field.Name(), field.Number())
+			}
+		}
+		b.WriteString("}\n")
+	}
+
+	return b.String()
+}
+
+func typeName(field protoreflect.FieldDescriptor) string {
+	if field.Kind() == protoreflect.MessageKind {
+		return string(field.Message().Name())
+	}
+
+	return field.Kind().String()
+}
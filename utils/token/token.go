@@ -0,0 +1,53 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Sign packs the given fields into a token tamper-proofed with an
+// HMAC-SHA256 of the secret
+func Sign(secret string, fields ...string) string {
+	payload, _ := json.Marshal(fields)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encodedPayload + "." + signature(secret, encodedPayload)
+}
+
+// Verify checks the token signature and returns the fields it carries
+func Verify(secret, token string) ([]string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidToken
+	}
+
+	expected := signature(secret, parts[0])
+	if !hmac.Equal([]byte(expected), []byte(parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var fields []string
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return fields, nil
+}
+
+func signature(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
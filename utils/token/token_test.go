@@ -0,0 +1,37 @@
+package token
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	signed := Sign("secret", "ROOM1", "player-1")
+
+	fields, err := Verify("secret", signed)
+	if err != nil {
+		t.Fatalf("token should be valid, got %v", err)
+	}
+
+	if !reflect.DeepEqual([]string{"ROOM1", "player-1"}, fields) {
+		t.Errorf("unexpected fields %v", fields)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	signed := Sign("secret", "ROOM1", "player-1")
+
+	if _, err := Verify("another secret", signed); err != ErrInvalidToken {
+		t.Errorf("token signed with another secret should be rejected")
+	}
+
+	forged := Sign("secret", "ROOM1", "player-2")
+	tampered := signed[:len(signed)-5] + forged[len(forged)-5:]
+	if _, err := Verify("secret", tampered); err != ErrInvalidToken {
+		t.Errorf("tampered token should be rejected")
+	}
+
+	if _, err := Verify("secret", "garbage"); err != ErrInvalidToken {
+		t.Errorf("malformed token should be rejected")
+	}
+}